@@ -0,0 +1,200 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-24 10:12:45
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-24 17:38:20
+ * @Description:机器激活：客户端生成激活请求，签发端返回授权令牌
+ */
+package authorization
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// activationPrefix 激活请求格式版本前缀
+const activationPrefix = "tka1."
+
+// DefaultActivationMaxAge 未设置 Issuer.MaxAge 时激活请求的有效期
+const DefaultActivationMaxAge = 7 * 24 * time.Hour
+
+// activationSkew 激活请求时间允许超前签发端的幅度，容忍两端时钟误差
+const activationSkew = 10 * time.Minute
+
+var (
+	ErrActivationMalformed = errors.New("authorization: malformed activation request")
+	ErrActivationSignature = errors.New("authorization: invalid activation request signature")
+	ErrActivationStale     = errors.New("authorization: activation request too old")
+	ErrActivationFuture    = errors.New("authorization: activation request from the future")
+)
+
+// ActivationRequest 激活请求，由客户端生成后复制或以二维码形式发给签发方
+type ActivationRequest struct {
+	MachineCode string    `json:"mc"`
+	Product     string    `json:"prd"`
+	Version     string    `json:"ver,omitempty"`
+	Time        time.Time `json:"t"`
+}
+
+// Encode 使用请求口令签名并编码
+// 格式 tka1.<base64url(载荷)>.<base64url(HMAC-SHA256 前 16 字节)>，只含 URL 安全字符，便于生成二维码（见 text.TextQRCodeTerminal）
+// 口令随客户端分发，可以从程序中提取，HMAC 只用于发现复制时的损坏和误改，不能证明请求来自正版客户端；
+// 请求中的机器码可以伪造，安全性来自签发方核对客户身份后签发、绑定机器码的 Ed25519 令牌
+func (r *ActivationRequest) Encode(key string) (string, error) {
+	if r.MachineCode == "" || r.Product == "" {
+		return "", fmt.Errorf("machine code and product are required")
+	}
+	payload, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return activationPrefix + body + "." + base64.RawURLEncoding.EncodeToString(activationMAC(key, body)), nil
+}
+
+// ParseActivationRequest 校验签名并解出激活请求，不检查有效期
+func ParseActivationRequest(blob, key string) (*ActivationRequest, error) {
+	blob = strings.Join(strings.Fields(blob), "") // 容忍复制时带入的换行和空格
+	if !strings.HasPrefix(blob, activationPrefix) {
+		return nil, ErrActivationMalformed
+	}
+	body, macText, ok := strings.Cut(strings.TrimPrefix(blob, activationPrefix), ".")
+	if !ok {
+		return nil, ErrActivationMalformed
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(macText)
+	if err != nil {
+		return nil, ErrActivationMalformed
+	}
+	if !hmac.Equal(mac, activationMAC(key, body)) {
+		return nil, ErrActivationSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrActivationMalformed
+	}
+	var r ActivationRequest
+	if err := json.Unmarshal(payload, &r); err != nil || r.MachineCode == "" || r.Product == "" {
+		return nil, ErrActivationMalformed
+	}
+	return &r, nil
+}
+
+func activationMAC(key, body string) []byte {
+	m := hmac.New(sha256.New, []byte(key))
+	m.Write([]byte(activationPrefix + body))
+	return m.Sum(nil)[:16]
+}
+
+// Issuer 签发端，把激活请求转换为绑定机器码和产品的授权令牌
+type Issuer struct {
+	PrivateKey ed25519.PrivateKey
+	RequestKey string        // 激活请求口令，与客户端 Activator.RequestKey 一致，只做完整性校验，见 ActivationRequest.Encode
+	MaxAge     time.Duration // 激活请求有效期，默认 DefaultActivationMaxAge
+}
+
+// Activate 校验激活请求并签发令牌
+// lic 提供客户、功能和过期时间，机器码和产品取自请求
+func (i *Issuer) Activate(blob string, lic License) (string, *ActivationRequest, error) {
+	req, err := ParseActivationRequest(blob, i.RequestKey)
+	if err != nil {
+		return "", nil, err
+	}
+	maxAge := i.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultActivationMaxAge
+	}
+	age := time.Since(req.Time)
+	if age > maxAge {
+		return "", req, fmt.Errorf("%w: created %s", ErrActivationStale, req.Time.Format(time.DateTime))
+	}
+	if age < -activationSkew {
+		return "", req, fmt.Errorf("%w: created %s", ErrActivationFuture, req.Time.Format(time.DateTime))
+	}
+	lic.MachineCode = req.MachineCode
+	lic.Product = req.Product
+	lic.IssuedAt = time.Now()
+	token, err := IssueToken(i.PrivateKey, lic)
+	if err != nil {
+		return "", req, err
+	}
+	return token, req, nil
+}
+
+// Activator 客户端激活流程
+type Activator struct {
+	Product    string
+	Version    string
+	Machine    string            // 本机机器码，Import 和 Load 必填
+	RequestKey string            // 激活请求口令，随程序分发，只做完整性校验
+	PublicKey  ed25519.PublicKey // 签发公钥
+	Path       string            // 授权文件保存路径，Import 和 Load 必填
+	// Revocations 可选，吊销列表包含本机机器码时 Import 和 Load 返回 ErrRevoked
+	Revocations *Revocations
+}
+
+var (
+	ErrActivationPath    = errors.New("authorization: activator path not set")    // 未设置 Activator.Path
+	ErrActivationMachine = errors.New("authorization: activator machine not set") // 未设置 Activator.Machine，不能跳过机器码绑定
+)
+
+// Request 生成激活请求
+func (a *Activator) Request() (string, error) {
+	r := &ActivationRequest{MachineCode: a.Machine, Product: a.Product, Version: a.Version, Time: time.Now().UTC().Truncate(time.Second)}
+	return r.Encode(a.RequestKey)
+}
+
+// Import 校验签发方返回的令牌，通过后写入 Path
+func (a *Activator) Import(response string) (*License, error) {
+	v, err := a.verifier()
+	if err != nil {
+		return nil, err
+	}
+	response = strings.Join(strings.Fields(response), "")
+	lic, err := v.Verify(response)
+	if err != nil {
+		return lic, err
+	}
+	if dir := filepath.Dir(a.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, err
+		}
+	}
+	tmp := a.Path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(response+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, a.Path); err != nil {
+		return nil, err
+	}
+	return lic, nil
+}
+
+// Load 校验已导入的授权文件
+func (a *Activator) Load() (*License, error) {
+	v, err := a.verifier()
+	if err != nil {
+		return nil, err
+	}
+	return v.VerifyFile(a.Path)
+}
+
+// verifier 检查必填项并返回绑定本机机器码的校验器
+func (a *Activator) verifier() (*TokenVerifier, error) {
+	if a.Path == "" {
+		return nil, ErrActivationPath
+	}
+	if strings.TrimSpace(a.Machine) == "" {
+		return nil, ErrActivationMachine
+	}
+	return &TokenVerifier{PublicKey: a.PublicKey, MachineCode: a.Machine, Product: a.Product, Revocations: a.Revocations}, nil
+}
@@ -0,0 +1,127 @@
+package authorization
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActivationFlow(t *testing.T) {
+	pub, priv, err := GenerateTokenKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	activator := &Activator{
+		Product:    "demo",
+		Version:    "1.2.0",
+		Machine:    "ABC123",
+		RequestKey: "request-secret",
+		PublicKey:  pub,
+		Path:       filepath.Join(t.TempDir(), "license", "demo.key"),
+	}
+	blob, err := activator.Request()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := &Issuer{PrivateKey: priv, RequestKey: "request-secret"}
+	// 复制时被折行也能识别
+	wrapped := blob[:20] + "\n  " + blob[20:]
+	token, req, err := issuer.Activate(wrapped, License{Customer: "acme", Features: []string{"export"}, ExpiresAt: time.Now().AddDate(1, 0, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.MachineCode != "ABC123" || req.Version != "1.2.0" {
+		t.Fatalf("request = %+v", req)
+	}
+
+	lic, err := activator.Import(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lic.Product != "demo" || lic.Customer != "acme" {
+		t.Fatalf("license = %+v", lic)
+	}
+	if _, err := activator.Load(); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	other := *activator
+	other.Machine = "OTHER"
+	if _, err := other.Load(); !errors.Is(err, ErrTokenMachine) {
+		t.Fatalf("other machine err = %v", err)
+	}
+	other = *activator
+	other.Product = "another"
+	if _, err := other.Import(token); !errors.Is(err, ErrTokenProduct) {
+		t.Fatalf("other product err = %v", err)
+	}
+	other = *activator
+	other.Path = ""
+	if _, err := other.Import(token); !errors.Is(err, ErrActivationPath) {
+		t.Fatalf("empty path import err = %v", err)
+	}
+	if _, err := other.Load(); !errors.Is(err, ErrActivationPath) {
+		t.Fatalf("empty path load err = %v", err)
+	}
+	other = *activator
+	other.Machine = ""
+	if _, err := other.Import(token); !errors.Is(err, ErrActivationMachine) {
+		t.Fatalf("empty machine import err = %v", err)
+	}
+	if _, err := other.Load(); !errors.Is(err, ErrActivationMachine) {
+		t.Fatalf("empty machine load err = %v", err)
+	}
+
+	// 吊销本机机器码后导入和加载都失败
+	list, err := SignRevocationList(priv, RevocationList{Version: 1, Machines: []string{"abc123"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	activator.Revocations = &Revocations{PublicKey: pub}
+	if err := activator.Revocations.Update(list); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := activator.Load(); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("revoked load err = %v", err)
+	}
+	if _, err := activator.Import(token); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("revoked import err = %v", err)
+	}
+}
+
+func TestActivationRequestRejected(t *testing.T) {
+	_, priv, _ := GenerateTokenKey()
+	issuer := &Issuer{PrivateKey: priv, RequestKey: "k", MaxAge: time.Hour}
+
+	blob, _ := (&ActivationRequest{MachineCode: "A", Product: "p", Time: time.Now()}).Encode("other")
+	if _, _, err := issuer.Activate(blob, License{}); !errors.Is(err, ErrActivationSignature) {
+		t.Fatalf("err = %v, want signature", err)
+	}
+
+	// 篡改机器码后签名失效
+	blob, _ = (&ActivationRequest{MachineCode: "A", Product: "p", Time: time.Now()}).Encode("k")
+	forged, _ := (&ActivationRequest{MachineCode: "B", Product: "p", Time: time.Now()}).Encode("k")
+	mixed := forged[:strings.LastIndex(forged, ".")] + blob[strings.LastIndex(blob, "."):]
+	if _, _, err := issuer.Activate(mixed, License{}); !errors.Is(err, ErrActivationSignature) {
+		t.Fatalf("err = %v, want signature", err)
+	}
+
+	stale, _ := (&ActivationRequest{MachineCode: "A", Product: "p", Time: time.Now().Add(-2 * time.Hour)}).Encode("k")
+	if _, _, err := issuer.Activate(stale, License{}); !errors.Is(err, ErrActivationStale) {
+		t.Fatalf("err = %v, want stale", err)
+	}
+	future, _ := (&ActivationRequest{MachineCode: "A", Product: "p", Time: time.Now().Add(time.Hour)}).Encode("k")
+	if _, _, err := issuer.Activate(future, License{}); !errors.Is(err, ErrActivationFuture) {
+		t.Fatalf("err = %v, want future", err)
+	}
+	skewed, _ := (&ActivationRequest{MachineCode: "A", Product: "p", Time: time.Now().Add(time.Minute)}).Encode("k")
+	if _, _, err := issuer.Activate(skewed, License{}); err != nil {
+		t.Fatalf("small skew err = %v", err)
+	}
+	if _, _, err := issuer.Activate("garbage", License{}); !errors.Is(err, ErrActivationMalformed) {
+		t.Fatalf("err = %v, want malformed", err)
+	}
+}
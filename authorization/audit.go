@@ -0,0 +1,437 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-26 09:30:14
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-26 16:52:38
+ * @Description:授权审计日志，JSON Lines 格式，哈希链防篡改
+ */
+package authorization
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrAuditTampered 审计日志的哈希链校验失败
+var ErrAuditTampered = errors.New("authorization: audit log tampered")
+
+// 审计事件类型
+const (
+	AuditFetch = "fetch" // 访问数据源
+	AuditCheck = "check" // 校验序列号
+)
+
+// AuditEntry 审计日志中的一行
+type AuditEntry struct {
+	Seq     uint64    `json:"seq"`
+	Time    time.Time `json:"time"`
+	Event   string    `json:"event"`
+	Sn      string    `json:"sn,omitempty"`
+	Source  string    `json:"source,omitempty"`
+	Valid   bool      `json:"valid"`            // check 为校验结果，fetch 为拉取是否成功
+	Reason  string    `json:"reason,omitempty"` // valid、expired、not_found 等，见 auditReason
+	Expires time.Time `json:"expires,omitzero"` // 判断时看到的到期时间
+	Count   int       `json:"count,omitempty"`  // 拉取到的条目数
+	Error   string    `json:"error,omitempty"`
+	Prev    string    `json:"prev"` // 上一行的 Hash
+	Hash    string    `json:"hash"` // 本行除 Hash 外内容与 Prev 的摘要
+}
+
+// AuditLog 只追加的审计日志，超过 MaxSize 时轮转为 Path.1、Path.2…
+// 轮转后哈希链跨文件延续；设置 Key 时使用 HMAC，没有密钥无法重新计算整条链。
+// 不设置 Key 时任何人都能改写后重算整条链，哈希链只能发现误改和局部编辑；
+// 删除末尾若干行或改写后重算也无法从日志本身发现，需要用 Head 取得链尾并保存在客户机器之外，校验时用 AuditPin 固定
+type AuditLog struct {
+	Path     string
+	Key      string // 可选的 HMAC 口令，为空时使用 SHA-256
+	MaxSize  int64  // 单个文件上限，默认 10MB
+	MaxFiles int    // 保留的轮转文件数，默认 5
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	seq  uint64
+	prev string
+}
+
+// Write 追加一条记录，自动填充 Seq、Time、Prev 和 Hash
+func (a *AuditLog) Write(e AuditEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		if err := a.open(); err != nil {
+			return err
+		}
+	}
+
+	// 写入成功后才推进序号和哈希链，失败的写入不会在链中留下空洞
+	e.Seq = a.seq + 1
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Prev = a.prev
+	hash, err := auditHash(a.Key, e)
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if a.size > 0 && a.size+int64(len(line)) > a.maxSize() {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := a.f.Write(line)
+	a.size += int64(n)
+	if err != nil {
+		// 可能只写入了一部分，关闭后下次写入时由 open 截断残行
+		a.f.Close()
+		a.f = nil
+		return err
+	}
+	a.seq, a.prev = e.Seq, e.Hash
+	return nil
+}
+
+// Close 关闭日志文件，之后再写入会重新打开
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		return nil
+	}
+	err := a.f.Close()
+	a.f = nil
+	return err
+}
+
+// Files 返回当前存在的日志文件，从旧到新排列
+func (a *AuditLog) Files() []string {
+	var files []string
+	for i := a.maxFiles(); i >= 1; i-- {
+		if _, err := os.Stat(a.rotated(i)); err == nil {
+			files = append(files, a.rotated(i))
+		}
+	}
+	if _, err := os.Stat(a.Path); err == nil {
+		files = append(files, a.Path)
+	}
+	return files
+}
+
+// AuditPin 校验时固定的期望值，零值字段不检查
+type AuditPin struct {
+	Head  string `json:"head"`  // 最后一行的 Hash
+	Seq   uint64 `json:"seq"`   // 最后一行的序号，即写入过的总条数（含已轮转删除的）
+	Count int    `json:"count"` // 现存日志文件中的条数
+}
+
+// Head 返回当前链尾，可上报到服务端，之后用 VerifyAuditLogPinned 发现截断和重算
+func (a *AuditLog) Head() (AuditPin, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.f == nil {
+		if err := a.open(); err != nil {
+			return AuditPin{}, err
+		}
+	}
+	return AuditPin{Head: a.prev, Seq: a.seq}, nil
+}
+
+// Verify 按从旧到新的顺序校验全部日志文件的哈希链，返回校验通过的条数
+// 最旧文件的第一行可能指向已删除的轮转文件，序号不为 1 时其 Prev 不做校验
+func (a *AuditLog) Verify() (int, error) {
+	return VerifyAuditLog(a.Key, a.Files()...)
+}
+
+// VerifyAuditLog 校验客户机器上传回的日志文件，files 需按从旧到新排列
+// 只校验日志内部是否自洽，见 AuditLog 的说明；需要固定链尾时使用 VerifyAuditLogPinned
+func VerifyAuditLog(key string, files ...string) (int, error) {
+	return VerifyAuditLogPinned(key, AuditPin{}, files...)
+}
+
+// VerifyAuditLogPinned 同 VerifyAuditLog，并要求链尾与 pin 一致，可发现删除末尾和不带 Key 时的整体重算
+func VerifyAuditLogPinned(key string, pin AuditPin, files ...string) (int, error) {
+	count := 0
+	prev, first := "", true
+	var seq uint64
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return count, err
+		}
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		line := 0
+		for sc.Scan() {
+			line++
+			if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+				continue
+			}
+			var e AuditEntry
+			if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+				f.Close()
+				return count, fmt.Errorf("%w: %s:%d: %v", ErrAuditTampered, filepath.Base(path), line, err)
+			}
+			want, err := auditHash(key, e)
+			if err != nil {
+				f.Close()
+				return count, err
+			}
+			chained := e.Prev == prev && e.Seq == seq+1
+			if first {
+				// 从头开始的日志第一行没有上一行；更早的行被轮转删除时无从校验
+				chained = e.Seq > 1 || e.Prev == ""
+			}
+			if e.Hash != want || !chained {
+				f.Close()
+				return count, fmt.Errorf("%w: %s:%d (seq %d)", ErrAuditTampered, filepath.Base(path), line, e.Seq)
+			}
+			prev, seq, first = e.Hash, e.Seq, false
+			count++
+		}
+		err = sc.Err()
+		f.Close()
+		if err != nil {
+			return count, err
+		}
+	}
+	switch {
+	case pin.Head != "" && prev != pin.Head:
+		return count, fmt.Errorf("%w: head %.12s, want %.12s", ErrAuditTampered, prev, pin.Head)
+	case pin.Seq != 0 && seq != pin.Seq:
+		return count, fmt.Errorf("%w: last seq %d, want %d", ErrAuditTampered, seq, pin.Seq)
+	case pin.Count != 0 && count != pin.Count:
+		return count, fmt.Errorf("%w: %d entries, want %d", ErrAuditTampered, count, pin.Count)
+	}
+	return count, nil
+}
+
+// auditHash 计算除 Hash 外全部字段的摘要
+func auditHash(key string, e AuditEntry) (string, error) {
+	e.Hash = ""
+	raw, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		sum := sha256.Sum256(raw)
+		return hex.EncodeToString(sum[:]), nil
+	}
+	m := hmac.New(sha256.New, []byte(key))
+	m.Write(raw)
+	return hex.EncodeToString(m.Sum(nil)), nil
+}
+
+// open 打开日志文件，从最后一行恢复序号和哈希链；调用时需持有锁
+func (a *AuditLog) open() error {
+	if dir := filepath.Dir(a.Path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(a.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	size, err := repairTail(f, info.Size())
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.f, a.size = f, size
+	a.seq, a.prev = 0, ""
+
+	last := lastLine(f, size)
+	if last == nil {
+		// 当前文件为空时从最近的轮转文件接续
+		if rf, err := os.Open(a.rotated(1)); err == nil {
+			if ri, err := rf.Stat(); err == nil {
+				last = lastLine(rf, ri.Size())
+			}
+			rf.Close()
+		}
+	}
+	if last != nil {
+		var e AuditEntry
+		if err := json.Unmarshal(last, &e); err == nil {
+			a.seq, a.prev = e.Seq, e.Hash
+		} else {
+			log.Println("Error resuming audit log:", err)
+		}
+	}
+	return nil
+}
+
+// rotate 关闭当前文件并依次重命名为 Path.1…Path.MaxFiles；调用时需持有锁
+func (a *AuditLog) rotate() error {
+	if err := a.f.Close(); err != nil {
+		return err
+	}
+	a.f = nil
+	os.Remove(a.rotated(a.maxFiles()))
+	for i := a.maxFiles() - 1; i >= 1; i-- {
+		os.Rename(a.rotated(i), a.rotated(i+1))
+	}
+	if err := os.Rename(a.Path, a.rotated(1)); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	a.f, a.size = f, 0
+	return nil
+}
+
+func (a *AuditLog) rotated(i int) string {
+	return a.Path + "." + strconv.Itoa(i)
+}
+
+func (a *AuditLog) maxSize() int64 {
+	if a.MaxSize <= 0 {
+		return 10 << 20
+	}
+	return a.MaxSize
+}
+
+func (a *AuditLog) maxFiles() int {
+	if a.MaxFiles <= 0 {
+		return 5
+	}
+	return a.MaxFiles
+}
+
+// lastLine 读取文件最后一个非空行，文件为空时返回 nil
+// repairTail 处理崩溃时写了一半的最后一行：能解析的补上换行，否则截断到最后一个完整行，返回处理后的大小
+func repairTail(f *os.File, size int64) (int64, error) {
+	if size == 0 {
+		return 0, nil
+	}
+	b := make([]byte, 1)
+	if _, err := f.ReadAt(b, size-1); err != nil {
+		return 0, err
+	}
+	if b[0] == '\n' {
+		return size, nil
+	}
+	tail := lastLine(f, size)
+	var e AuditEntry
+	if json.Unmarshal(tail, &e) == nil {
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			return 0, err
+		}
+		return size + 1, nil
+	}
+	end := size - int64(len(tail))
+	log.Printf("audit log %s: dropping %d bytes of partial entry", f.Name(), len(tail))
+	if err := f.Truncate(end); err != nil {
+		return 0, err
+	}
+	return end, nil
+}
+
+func lastLine(r io.ReaderAt, size int64) []byte {
+	const chunk = 4096
+	var buf []byte
+	for off := size; off > 0; {
+		n := int64(chunk)
+		if off < n {
+			n = off
+		}
+		off -= n
+		part := make([]byte, n)
+		if _, err := r.ReadAt(part, off); err != nil && err != io.EOF {
+			return nil
+		}
+		buf = append(part, buf...)
+		trimmed := bytes.TrimRight(buf, "\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:]
+		}
+		if off == 0 && len(trimmed) > 0 {
+			return trimmed
+		}
+	}
+	return nil
+}
+
+// SetAuditLog 设置审计日志，记录每次数据源访问和校验，传 nil 关闭
+func (c *Client) SetAuditLog(a *AuditLog) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.audit = a
+}
+
+// auditWrite 写入审计日志，失败只记录日志
+func (c *Client) auditWrite(e AuditEntry) {
+	c.mu.Lock()
+	a := c.audit
+	c.mu.Unlock()
+	if a == nil {
+		return
+	}
+	if err := a.Write(e); err != nil {
+		log.Println("Error writing audit log:", err)
+	}
+}
+
+// auditCheck 记录一次校验结果
+func (c *Client) auditCheck(r *Result) {
+	e := AuditEntry{Event: AuditCheck, Sn: r.Key, Source: r.Source, Valid: r.Valid, Reason: auditReason(r), Expires: r.Expires}
+	if r.Err != nil {
+		e.Error = r.Err.Error()
+	}
+	c.auditWrite(e)
+}
+
+// auditReason 将校验结果归类为简短的原因
+func auditReason(r *Result) string {
+	switch {
+	case r.Valid && r.Trial != nil:
+		return "trial"
+	case r.Valid:
+		return "valid"
+	case errors.Is(r.Err, ErrRevoked):
+		return "revoked"
+	case errors.Is(r.Err, ErrClockTampered):
+		return "clock_tampered"
+	case errors.Is(r.Err, ErrTrialTampered):
+		return "trial_tampered"
+	case errors.Is(r.Err, ErrTrialExpired):
+		return "trial_expired"
+	case errors.Is(r.Err, ErrExpired):
+		return "expired"
+	case errors.Is(r.Err, ErrFetchFailed):
+		return "fetch_failed"
+	case errors.Is(r.Err, ErrNotFound):
+		return "not_found"
+	case errors.Is(r.Err, ErrUnparseableTime):
+		return "unparseable_time"
+	default:
+		return "invalid"
+	}
+}
@@ -0,0 +1,236 @@
+package authorization
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readAudit(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestAuditLogRecordsFetchAndCheck(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit := &AuditLog{Path: path, Key: "secret"}
+	client := NewSourceClient(
+		&stubSource{name: "down", err: errors.New("timeout")},
+		&stubSource{name: "stub", data: []Accredit{{Sn: "A", Time: "2000-01-01"}}},
+	)
+	client.SetAuditLog(audit)
+	client.CheckAccredit("A")
+	client.CheckAccredit("B")
+	audit.Close()
+
+	entries := readAudit(t, path)
+	if len(entries) != 4 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if e := entries[0]; e.Event != AuditFetch || e.Source != "down" || e.Error == "" || e.Valid {
+		t.Fatalf("failed fetch = %+v", e)
+	}
+	if e := entries[1]; e.Event != AuditFetch || e.Count != 1 || !e.Valid {
+		t.Fatalf("fetch = %+v", e)
+	}
+	if e := entries[2]; e.Event != AuditCheck || e.Sn != "A" || e.Reason != "expired" || e.Expires.Year() != 2000 || e.Source != "stub" {
+		t.Fatalf("check = %+v", e)
+	}
+	if e := entries[3]; e.Reason != "not_found" || e.Seq != 4 || e.Prev != entries[2].Hash {
+		t.Fatalf("check = %+v", e)
+	}
+
+	if n, err := audit.Verify(); err != nil || n != 4 {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+	if _, err := VerifyAuditLog("other-key", path); !errors.Is(err, ErrAuditTampered) {
+		t.Fatalf("wrong key err = %v", err)
+	}
+
+	// 修改一行内容后校验失败
+	raw, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.Replace(string(raw), `"reason":"expired"`, `"reason":"valid"`, 1)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := audit.Verify(); !errors.Is(err, ErrAuditTampered) || n != 2 {
+		t.Fatalf("tampered Verify = %d, %v", n, err)
+	}
+}
+
+func TestAuditLogRotatesAndResumesChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit := &AuditLog{Path: path, MaxSize: 600, MaxFiles: 2}
+	for i := 0; i < 12; i++ {
+		if err := audit.Write(AuditEntry{Event: AuditCheck, Sn: "A"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	audit.Close()
+
+	// 重新打开后序号和哈希链接续
+	reopened := &AuditLog{Path: path, MaxSize: 600, MaxFiles: 2}
+	if err := reopened.Write(AuditEntry{Event: AuditCheck, Sn: "B"}); err != nil {
+		t.Fatal(err)
+	}
+	reopened.Close()
+
+	files := reopened.Files()
+	if len(files) != 3 || files[0] != path+".2" || files[2] != path {
+		t.Fatalf("files = %v", files)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatal("rotated file beyond MaxFiles kept")
+	}
+	n, err := reopened.Verify()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := readAudit(t, path)
+	if last := entries[len(entries)-1]; last.Seq != 13 || last.Sn != "B" {
+		t.Fatalf("last entry = %+v", last)
+	}
+	if n >= 13 {
+		t.Fatalf("verified %d entries, expected oldest ones rotated away", n)
+	}
+}
+
+// writeAudit 用 key 重新计算整条链并覆盖日志文件
+func writeAudit(t *testing.T, path, key string, entries []AuditEntry) {
+	t.Helper()
+	var b strings.Builder
+	prev := ""
+	for _, e := range entries {
+		e.Prev = prev
+		hash, err := auditHash(key, e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Hash, prev = hash, hash
+		line, _ := json.Marshal(e)
+		b.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyAuditLogPinned(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit := &AuditLog{Path: path}
+	for _, sn := range []string{"A", "B", "C"} {
+		if err := audit.Write(AuditEntry{Event: AuditCheck, Sn: sn}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pin, err := audit.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit.Close()
+	pin.Count = 3
+	if n, err := VerifyAuditLogPinned("", pin, path); err != nil || n != 3 {
+		t.Fatalf("pinned verify = %d, %v", n, err)
+	}
+	entries := readAudit(t, path)
+
+	// 删除最后一行：链本身完好，只有固定链尾才能发现
+	writeAudit(t, path, "", entries[:2])
+	if _, err := VerifyAuditLog("", path); err != nil {
+		t.Fatalf("truncated chain should be self-consistent: %v", err)
+	}
+	if _, err := VerifyAuditLogPinned("", pin, path); !errors.Is(err, ErrAuditTampered) {
+		t.Fatalf("truncated err = %v", err)
+	}
+
+	// 没有 Key 时改写内容后可以重算整条链
+	forged := append([]AuditEntry(nil), entries...)
+	forged[1].Valid = !forged[1].Valid
+	writeAudit(t, path, "", forged)
+	if _, err := VerifyAuditLog("", path); err != nil {
+		t.Fatalf("recomputed chain should be self-consistent: %v", err)
+	}
+	if _, err := VerifyAuditLogPinned("", AuditPin{Head: pin.Head}, path); !errors.Is(err, ErrAuditTampered) {
+		t.Fatalf("recomputed err = %v", err)
+	}
+
+	// 从头开始的日志第一行必须没有上一行，序号必须连续
+	forged = append([]AuditEntry(nil), entries...)
+	forged[2].Seq = 5
+	writeAudit(t, path, "", forged)
+	if _, err := VerifyAuditLog("", path); !errors.Is(err, ErrAuditTampered) {
+		t.Fatalf("seq gap err = %v", err)
+	}
+	writeAudit(t, path, "", entries)
+	raw, _ := os.ReadFile(path)
+	first := entries[0]
+	first.Prev = strings.Repeat("0", 64)
+	first.Hash, _ = auditHash("", first)
+	line, _ := json.Marshal(first)
+	rest := strings.SplitN(string(raw), "\n", 2)[1]
+	if err := os.WriteFile(path, []byte(string(line)+"\n"+rest), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyAuditLog("", path); !errors.Is(err, ErrAuditTampered) || !strings.Contains(err.Error(), ":1 ") {
+		t.Fatalf("first prev err = %v", err)
+	}
+}
+
+func TestAuditLogRecoversPartialTailAndFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit := &AuditLog{Path: path, Key: "secret"}
+	for _, sn := range []string{"A", "B"} {
+		if err := audit.Write(AuditEntry{Event: AuditCheck, Sn: sn}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	audit.Close()
+
+	// 崩溃时只写了半行
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"seq":3,"ev":"ch`)
+	f.Close()
+	if err := audit.Write(AuditEntry{Event: AuditCheck, Sn: "C"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 写入失败不推进序号
+	audit.mu.Lock()
+	audit.f.Close()
+	audit.mu.Unlock()
+	if err := audit.Write(AuditEntry{Event: AuditCheck, Sn: "lost"}); err == nil {
+		t.Fatal("write to closed file succeeded")
+	}
+	if err := audit.Write(AuditEntry{Event: AuditCheck, Sn: "D"}); err != nil {
+		t.Fatal(err)
+	}
+	audit.Close()
+
+	if n, err := audit.Verify(); err != nil || n != 4 {
+		t.Fatalf("Verify = %d, %v", n, err)
+	}
+	entries := readAudit(t, path)
+	if last := entries[len(entries)-1]; last.Seq != 4 || last.Sn != "D" {
+		t.Fatalf("last entry = %+v", last)
+	}
+}
@@ -8,7 +8,9 @@
 package authorization
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -21,29 +23,55 @@ import (
 )
 
 // 预编译正则以提升性能
-var (
-	reJumpURL = regexp.MustCompile(`var jump_url="(.*?)";`)
-	reTable   = regexp.MustCompile(`(?s)(<table.*?</table>)`)
-	reRow     = regexp.MustCompile(`(?s)<tr.*?>(.*?)</tr>`)
-	reCell    = regexp.MustCompile(`(?s)<td.*?>(.*?)</td>`)
-	reTags    = regexp.MustCompile(`<[^>]*>`)
-)
+var reJumpURL = regexp.MustCompile(`var jump_url="(.*?)";`)
 
 // Accredit 导出结构体，包含授权信息的序列号和时间
 type Accredit struct {
-	Sn   string
-	Time string
+	Sn       string            `json:"sn"`
+	Time     string            `json:"time"`
+	Expires  time.Time         `json:"expires"`            // 解析后的到期时间，无法解析时为零值
+	Fields   map[string]string `json:"fields,omitempty"`   // 整行数据，键为表头名，无表头时为 col1、col2…
+	Row      int               `json:"row,omitempty"`      // 在数据源中的行号（从 1 开始，不含表头）
+	Features Entitlements      `json:"features,omitempty"` // 功能项，来自功能列
 }
 
 // Client 授权客户端，用于管理请求和缓存
 type Client struct {
-	Code       string //二维码网址
-	Pwd        string //密码
-	Data       []Accredit
-	mu         sync.Mutex
-	httpClient *http.Client
+	Code        string //二维码网址
+	Pwd         string //密码
+	Data        []Accredit
+	mu          sync.Mutex
+	httpClient  *http.Client
+	sources     []Source
+	cache       *Cache
+	retry       RetryPolicy
+	table       TableSelector
+	endpoints   Endpoints
+	clock       *Clock
+	loc         *time.Location
+	parser      ExpiryParser
+	featureCol  string
+	trial       *Trial
+	audit       *AuditLog
+	revocations *Revocations
+	groups      map[string]map[string]bool // 组名 → 成员，均已 NormalizeSn
+	minRefresh  time.Duration              // 最小刷新间隔
+	inflight    *fetchCall                 // 进行中的拉取
+	lastAttempt time.Time                  // 上次拉取完成的时间
+	lastErr     error                      // 上次拉取的错误
+	source      string                     // 当前 Data 的来源
+	fetchedAt   time.Time                  // 当前 Data 的拉取时间
+	now         func() time.Time           // 判断缓存新鲜期和宽限期的时间来源，为空时使用 time.Now，测试用
 }
 
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// 默认数据源的线上地址
+const (
+	DefaultClewmBaseURL    = "https://"
+	DefaultCaoliaoEndpoint = "https://nc.caoliao.net/batch-requests"
+)
+
 // NewClient 创建一个新的客户端实例
 func NewClient(code string, pwd ...string) *Client {
 	p := ""
@@ -54,188 +82,384 @@ func NewClient(code string, pwd ...string) *Client {
 		Code:       code,
 		Pwd:        p,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
+		retry:      DefaultRetry,
+		minRefresh: DefaultMinRefreshInterval,
+	}
+}
+
+// NewSourceClient 使用自定义数据源链创建客户端，Refresh 时按顺序依次尝试
+func NewSourceClient(sources ...Source) *Client {
+	c := NewClient("")
+	c.sources = sources
+	c.minRefresh = 0 // 自定义数据源自行控制访问频率
+	return c
+}
+
+// SetSources 替换数据源链，传空则恢复为按 Code/Pwd 生成的默认链
+func (c *Client) SetSources(sources ...Source) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sources = append([]Source(nil), sources...)
+}
+
+// SetCache 启用离线缓存，传 nil 关闭
+func (c *Client) SetCache(cache *Cache) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = cache
+}
+
+// SetRetry 设置默认数据源和 GetAccredit/GetAccredit2 的重试策略
+func (c *Client) SetRetry(p RetryPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.retry = p
+}
+
+// Endpoints 默认数据源的服务地址，为空使用线上地址，测试时可指向本地服务
+type Endpoints struct {
+	ClewmBaseURL    string // 旧版活码地址前缀，拼接 Code 得到页面地址，默认 DefaultClewmBaseURL
+	CaoliaoEndpoint string // 新版活码 batch-requests 接口地址，默认 DefaultCaoliaoEndpoint
+}
+
+// SetEndpoints 设置默认数据源的服务地址
+func (c *Client) SetEndpoints(e Endpoints) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endpoints = e
+}
+
+// SetHTTPClient 替换默认数据源使用的 http.Client
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.httpClient = hc
+}
+
+// SetClock 启用防回拨时钟，传 nil 关闭
+// clock.TrustServerTime 为真时，默认数据源响应的 Date 头会作为可信时间
+func (c *Client) SetClock(clock *Clock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+}
+
+// SetLocation 设置解析不带时区的到期时间时使用的时区，默认 time.Local
+// 例如服务器运行在 UTC，而授权表按北京时间填写时设为 Asia/Shanghai
+func (c *Client) SetLocation(loc *time.Location) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loc = loc
+}
+
+// SetExpiryParser 替换到期时间解析器，传 nil 恢复为 ParseExpiry
+func (c *Client) SetExpiryParser(p ExpiryParser) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.parser = p
+}
+
+// SetTableSelector 设置默认数据源从页面中选择哪个表格，默认第一个
+func (c *Client) SetTableSelector(sel TableSelector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.table = sel
+}
+
+// sourceChain 返回当前生效的数据源链
+// 未自定义时：配置了密码优先新版活码接口，再回退到旧版网页解析
+func (c *Client) sourceChain() []Source {
+	c.mu.Lock()
+	custom := append([]Source(nil), c.sources...)
+	pwd := c.Pwd
+	c.mu.Unlock()
+	if len(custom) > 0 {
+		return custom
+	}
+	var chain []Source
+	if pwd != "" {
+		chain = append(chain, c.caoliaoSource())
 	}
+	return append(chain, c.clewmSource())
+}
+
+// clewmSource 按客户端配置生成旧版活码数据源
+func (c *Client) clewmSource() *ClewmSource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	retry := c.retry
+	return &ClewmSource{Code: c.Code, BaseURL: c.endpoints.ClewmBaseURL, Table: c.table, HTTPClient: c.httpClient, Retry: &retry}
+}
+
+// caoliaoSource 按客户端配置生成新版活码数据源
+func (c *Client) caoliaoSource() *CaoliaoSource {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	retry := c.retry
+	return &CaoliaoSource{Code: c.Code, Pwd: c.Pwd, Endpoint: c.endpoints.CaoliaoEndpoint, Table: c.table, HTTPClient: c.httpClient, Retry: &retry}
 }
 
 // GetAccredit 获取授权信息，更新内部缓存并返回数据 旧版活码
 // url格式 active.clewm.net/q8tDtnl
 func (c *Client) GetAccredit() ([]Accredit, error) {
-	url := "https://" + c.Code
-	// 使用共享的 httpClient
-	req, err := http.NewRequest("GET", url, nil)
+	return c.GetAccreditContext(context.Background())
+}
+
+// GetAccreditContext 同 GetAccredit，重试等待和请求均受 ctx 控制
+func (c *Client) GetAccreditContext(ctx context.Context) ([]Accredit, error) {
+	data, err := c.clewmSource().Fetch(c.sourceContext(ctx))
 	if err != nil {
 		return nil, err
 	}
+	return c.store(data, "clewm", time.Now()), nil
+}
 
-	jumpURL := ""
-	tableData := make(map[string]string)
+// fetchClewm 解析旧版活码页面中的 jump_url，再从跳转页面提取授权表格
+func fetchClewm(ctx context.Context, s *ClewmSource) ([]Accredit, error) {
+	client, retry := httpClientOrDefault(s.HTTPClient), retryOrDefault(s.Retry)
+	base := s.BaseURL
+	if base == "" {
+		base = DefaultClewmBaseURL
+	}
 
 	// 重试获取 jump_url 逻辑
-	for i := 0; i < 2; i++ {
-		resp, err := c.httpClient.Do(req)
+	var jumpURL string
+	err := retry.do(ctx, func() error {
+		bodyText, err := httpGet(ctx, client, base+s.Code, nil)
 		if err != nil {
 			log.Println("Error during request:", err)
-			time.Sleep(3 * time.Second) // 等待3秒
-			continue
-		}
-
-		bodyText, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			log.Println("Error reading body:", err)
-			time.Sleep(3 * time.Second) // 等待3秒
-			continue
+			return err
 		}
-
 		match := reJumpURL.FindStringSubmatch(string(bodyText))
-
-		if len(match) > 1 {
-			jumpURL = match[1]
-			// fmt.Println("jump_url:", jumpURL)
-			break // 找到 jump_url, 退出循环
-		} else {
+		if len(match) < 2 {
 			log.Println("jump_url not found, retrying...")
-			time.Sleep(3 * time.Second) // 等待3秒
+			return fmt.Errorf("jump_url not found")
 		}
+		jumpURL = match[1]
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if jumpURL != "" {
-		// 创建新的请求以应用 Header
-		req, err := http.NewRequest("GET", jumpURL, nil)
+	header := http.Header{}
+	header.Set("accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
+	header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36 Edg/143.0.0.0")
+
+	// 重试获取 tableData 逻辑
+	var tableData []Accredit
+	err = retry.do(ctx, func() error {
+		bodyText, err := httpGet(ctx, client, jumpURL, header)
 		if err != nil {
-			return nil, err
+			log.Println("Error following jump URL:", err)
+			return err
 		}
-		req.Header.Set("accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
-		req.Header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36 Edg/143.0.0.0")
-
-		// 重试获取 tableData 逻辑
-		for i := 0; i < 2; i++ {
-			resp, err := c.httpClient.Do(req)
-			if err != nil {
-				log.Println("Error following jump URL:", err)
-				time.Sleep(3 * time.Second)
-				continue
-			}
-
-			bodyText, err := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			if err != nil {
-				log.Println("Error reading body from jump URL:", err)
-				time.Sleep(3 * time.Second)
-				continue
-			}
-
-			match := reTable.FindStringSubmatch(string(bodyText))
-
-			if len(match) > 1 {
-				tableHTML := match[1]
-				// 使用公共方法解析表格
-				tableData = parseHTMLTable(tableHTML)
-				break
-			} else {
-				log.Println("Table not found, retrying...")
-				time.Sleep(3 * time.Second) // 等待3秒
-			}
+		tableData, err = htmlToAccredits(string(bodyText), s.Table, expiryParserFrom(ctx))
+		if err != nil {
+			log.Println("Table not found, retrying...")
 		}
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	if len(tableData) == 0 {
 		return nil, fmt.Errorf("no data found")
 	}
+	return tableData, nil
+}
 
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.Data = make([]Accredit, 0, len(tableData))
-	for k, v := range tableData {
-		c.Data = append(c.Data, Accredit{Sn: k, Time: v})
+// htmlToAccredits 按 sel 选择页面中的表格并解析为授权列表，第一列为序列号，第二列为时间
+func htmlToAccredits(doc string, sel TableSelector, parse expiryFunc) ([]Accredit, error) {
+	table, err := sel.Select(ParseHTMLTables(doc))
+	if err != nil {
+		return nil, err
 	}
-	return cloneAccredits(c.Data), nil
+	header, rows := splitHeader(table.Rows, table.HasHeader || sel.HasHeader, 0, 1)
+	return rowsToAccredits(rows, header, 0, 1, parse), nil
 }
 
-// removeHTMLTags 函数用于移除 HTML 标签
-func removeHTMLTags(html string) string {
-	return reTags.ReplaceAllString(html, "")
+// Refresh 按顺序遍历数据源，使用第一个成功返回的数据更新内部缓存
+func (c *Client) Refresh() ([]Accredit, error) {
+	return c.RefreshContext(context.Background())
 }
 
-// parseHTMLTable 解析 HTML 表格数据
-func parseHTMLTable(html string) map[string]string {
-	data := make(map[string]string)
-	rows := reRow.FindAllStringSubmatch(html, -1)
-	for _, row := range rows {
-		cells := reCell.FindAllStringSubmatch(row[1], -1)
-		if len(cells) >= 2 {
-			sn := removeHTMLTags(cells[0][1])
-			t := removeHTMLTags(cells[1][1])
-			data[sn] = t
+// RefreshContext 同 Refresh，ctx 结束时立即停止并返回 ctx.Err()
+func (c *Client) RefreshContext(ctx context.Context) ([]Accredit, error) {
+	entry, err := c.refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Data, nil
+}
+
+// fetchSources 遍历数据源，返回成功的数据及其来源
+func (c *Client) fetchSources(ctx context.Context) (*CacheEntry, error) {
+	ctx = c.sourceContext(ctx)
+	c.refreshRevocations(ctx)
+	var errs []error
+	for _, src := range c.sourceChain() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := src.Fetch(ctx)
+		if err == nil && len(data) == 0 {
+			err = fmt.Errorf("no data found")
 		}
+		if err != nil {
+			log.Printf("source %s failed: %v", src.Name(), err)
+			c.auditWrite(AuditEntry{Event: AuditFetch, Source: src.Name(), Error: err.Error()})
+			errs = append(errs, fmt.Errorf("%s: %w", src.Name(), err))
+			continue
+		}
+		c.auditWrite(AuditEntry{Event: AuditFetch, Source: src.Name(), Valid: true, Count: len(data)})
+		for _, dup := range Duplicates(data) {
+			log.Printf("source %s: duplicate sn %q in %d rows", src.Name(), dup[0].Sn, len(dup))
+		}
+		now := c.timeNow()
+		c.saveCache(data, src.Name(), now)
+		return &CacheEntry{FetchedAt: now, Source: src.Name(), Data: c.store(data, src.Name(), now)}, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no source configured")
 	}
-	return data
+	return nil, errors.Join(errs...)
 }
 
-// CheckAccredit 检查指定 key 的授权是否有效
-func (c *Client) CheckAccredit(key string) bool {
+// loadData 返回用于校验的授权列表及其来源
+// 顺序：新鲜期内的内存数据 → 新鲜期内的离线缓存 → 数据源 → 宽限期内的内存数据或离线缓存
+// 未设置 Cache 时内存数据一直有效；设置后内存数据与离线缓存一样受新鲜期和宽限期约束
+func (c *Client) loadData(ctx context.Context) (*CacheEntry, error) {
 	c.mu.Lock()
-	data := cloneAccredits(c.Data)
+	current := &CacheEntry{FetchedAt: c.fetchedAt, Source: c.source, Data: cloneAccredits(c.Data)}
+	cache := c.cache
 	c.mu.Unlock()
+	now := c.timeNow()
+	if len(current.Data) > 0 && (cache == nil || cache.Fresh(current, now)) {
+		return current, nil
+	}
 
-	if len(data) == 0 {
-		var err error
-		// 1. 如果配置了密码，优先尝试 GetAccredit2 (API方式)
-		if c.Pwd != "" {
-			data, err = c.GetAccredit2()
+	var entry *CacheEntry
+	if len(current.Data) > 0 {
+		entry = current
+	}
+	if cache != nil {
+		e, err := cache.Load()
+		if err == nil && len(e.Data) > 0 && (entry == nil || e.FetchedAt.After(entry.FetchedAt)) {
+			entry = e
+			if cache.Fresh(e, now) {
+				c.store(e.Data, e.Source, e.FetchedAt)
+				return e, nil
+			}
 		}
+	}
 
-		// 2. 如果没有数据（未配置密码或API失败），尝试 GetAccredit (网页解析方式)
-		if len(data) == 0 {
-			data, err = c.GetAccredit()
+	fetched, err := c.refresh(ctx)
+	if err == nil {
+		return fetched, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if entry == nil {
+		return nil, err
+	}
+	if cache.Usable(entry, c.timeNow()) {
+		log.Printf("all sources failed, using offline data fetched at %s from %s", entry.FetchedAt.Format(time.DateTime), entry.Source)
+		if entry != current {
+			c.store(entry.Data, entry.Source, entry.FetchedAt)
 		}
+		return entry, nil
+	}
+	return nil, fmt.Errorf("data fetched at %s is past the grace period: %w", entry.FetchedAt.Format(time.DateTime), err)
+}
 
-		if err != nil {
-			return false
-		}
+// sourceContext 将客户端的时间解析配置传给数据源；设置了信任服务器时间的 Clock 时，让所有数据源的响应参与校时
+func (c *Client) sourceContext(ctx context.Context) context.Context {
+	c.mu.Lock()
+	clock := c.clock
+	c.mu.Unlock()
+	return withExpiryParser(withServerClock(ctx, clock), c.parseExpiry)
+}
+
+// timeNow 返回判断缓存有效期使用的当前时间
+func (c *Client) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
 	}
+	return time.Now()
+}
 
-	for _, item := range data {
-		if item.Sn == key {
-			return c.isTimeValid(item.Time)
-		}
+// saveCache 将拉取结果写入离线缓存，失败只记录日志
+func (c *Client) saveCache(data []Accredit, source string, fetchedAt time.Time) {
+	c.mu.Lock()
+	cache := c.cache
+	c.mu.Unlock()
+	if cache == nil {
+		return
 	}
+	if err := cache.Save(&CacheEntry{FetchedAt: fetchedAt, Source: source, Data: data}); err != nil {
+		log.Println("Error saving cache:", err)
+	}
+}
+
+// CheckAccredit 检查指定 key 的授权是否有效
+func (c *Client) CheckAccredit(key string) bool {
+	return c.CheckAccreditContext(context.Background(), key)
+}
 
-	return false
+// CheckAccreditContext 同 CheckAccredit，ctx 取消或超时视为校验失败
+func (c *Client) CheckAccreditContext(ctx context.Context, key string) bool {
+	return c.CheckAccreditDetailedContext(ctx, key).Valid
 }
 
 // isTimeValid 辅助方法：验证时间字符串是否有效且未过期
 func (c *Client) isTimeValid(val string) bool {
-	timeLayouts := []string{
-		"2006-01-02 15:04:05",
-		"2006/01/02 15:04:05",
-	}
-	dateLayouts := []string{
-		"2006-01-02",
-		"2006/01/02",
-	}
+	expires, err := c.parseExpiry(val)
+	return err == nil && !time.Now().After(expires)
+}
 
-	for _, layout := range timeLayouts {
-		if t, err := time.ParseInLocation(layout, strings.TrimSpace(val), time.Local); err == nil {
-			return !time.Now().After(t)
-		}
+// parseExpiry 使用客户端配置的解析器和时区解析到期时间
+func (c *Client) parseExpiry(val string) (time.Time, error) {
+	c.mu.Lock()
+	parser, loc := c.parser, c.loc
+	c.mu.Unlock()
+	if parser == nil {
+		parser = ParseExpiry
 	}
-
-	for _, layout := range dateLayouts {
-		if t, err := time.ParseInLocation(layout, strings.TrimSpace(val), time.Local); err == nil {
-			endOfDay := t.Add(24*time.Hour - time.Nanosecond)
-			return !time.Now().After(endOfDay)
-		}
+	if loc == nil {
+		loc = time.Local
 	}
-	return false
+	return parser(val, loc)
 }
 
 // GetAccredit2 获取授权信息，更新内部缓存并返回数据 新版活码
 // url格式 qr61.cn/o78kxB/q8tDtnl
 func (c *Client) GetAccredit2() ([]Accredit, error) {
+	return c.GetAccredit2Context(context.Background())
+}
+
+// GetAccredit2Context 同 GetAccredit2，重试等待和请求均受 ctx 控制
+func (c *Client) GetAccredit2Context(ctx context.Context) ([]Accredit, error) {
+	data, err := c.caoliaoSource().Fetch(c.sourceContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return c.store(data, "caoliao", time.Now()), nil
+}
+
+// fetchCaoliao 通过新版活码的 batch-requests 接口获取授权表格
+func fetchCaoliao(ctx context.Context, s *CaoliaoSource) ([]Accredit, error) {
+	client, retry := httpClientOrDefault(s.HTTPClient), retryOrDefault(s.Retry)
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultCaoliaoEndpoint
+	}
+
 	formBody := url.Values{
-		"qrcode_route":            []string{c.Code},
-		"password":                []string{c.Pwd},
+		"qrcode_route":            []string{s.Code},
+		"password":                []string{s.Pwd},
 		"render_default_fields":   []string{"0"},
 		"render_component_number": []string{"0"},
 		"render_edit_btn":         []string{"1"},
@@ -273,35 +497,40 @@ func (c *Client) GetAccredit2() ([]Accredit, error) {
 		return nil, err
 	}
 
-	var data = strings.NewReader(string(payloadBody))
-	req, err := http.NewRequest("POST", "https://nc.caoliao.net/batch-requests", data)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("accept", "application/json, text/javascript, */*; q=0.01")
-	req.Header.Set("accept-language", "zh-CN,zh-TW;q=0.9,zh;q=0.8")
-	req.Header.Set("cache-control", "no-cache")
-	req.Header.Set("content-type", "application/json")
-	req.Header.Set("dnt", "1")
-	req.Header.Set("origin", "https://h5.clewm.net")
-	req.Header.Set("pragma", "no-cache")
-	req.Header.Set("priority", "u=1, i")
-	req.Header.Set("referer", "https://h5.clewm.net/")
-	req.Header.Set("sec-ch-ua", `"Microsoft Edge";v="143", "Chromium";v="143", "Not A(Brand";v="24"`)
-	req.Header.Set("sec-ch-ua-mobile", "?0")
-	req.Header.Set("sec-ch-ua-platform", `"Windows"`)
-	req.Header.Set("sec-fetch-dest", "empty")
-	req.Header.Set("sec-fetch-mode", "cors")
-	req.Header.Set("sec-fetch-site", "cross-site")
-	req.Header.Set("sec-fetch-storage-access", "active")
-	req.Header.Set("sec-gpc", "1")
-	req.Header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36 Edg/143.0.0.0")
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	bodyText, err := io.ReadAll(resp.Body)
+	var bodyText []byte
+	err = retry.do(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(string(payloadBody)))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("accept", "application/json, text/javascript, */*; q=0.01")
+		req.Header.Set("accept-language", "zh-CN,zh-TW;q=0.9,zh;q=0.8")
+		req.Header.Set("cache-control", "no-cache")
+		req.Header.Set("content-type", "application/json")
+		req.Header.Set("dnt", "1")
+		req.Header.Set("origin", "https://h5.clewm.net")
+		req.Header.Set("pragma", "no-cache")
+		req.Header.Set("priority", "u=1, i")
+		req.Header.Set("referer", "https://h5.clewm.net/")
+		req.Header.Set("sec-ch-ua", `"Microsoft Edge";v="143", "Chromium";v="143", "Not A(Brand";v="24"`)
+		req.Header.Set("sec-ch-ua-mobile", "?0")
+		req.Header.Set("sec-ch-ua-platform", `"Windows"`)
+		req.Header.Set("sec-fetch-dest", "empty")
+		req.Header.Set("sec-fetch-mode", "cors")
+		req.Header.Set("sec-fetch-site", "cross-site")
+		req.Header.Set("sec-fetch-storage-access", "active")
+		req.Header.Set("sec-gpc", "1")
+		req.Header.Set("user-agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36 Edg/143.0.0.0")
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Println("Error during request:", err)
+			return err
+		}
+		defer resp.Body.Close()
+		ObserveServerDate(ctx, resp)
+		bodyText, err = io.ReadAll(resp.Body)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -334,22 +563,39 @@ func (c *Client) GetAccredit2() ([]Accredit, error) {
 	if err := json.Unmarshal([]byte(result.Responses[0].Body), &innerData); err != nil {
 		return nil, err
 	}
-	tableData := make(map[string]string)
+	var tableData []Accredit
 	if len(innerData.Data.QrcodeMsg.QrcodeComponent) > 0 && len(innerData.Data.QrcodeMsg.QrcodeComponent[0].AttributeList) > 0 {
 		htmlValue := innerData.Data.QrcodeMsg.QrcodeComponent[0].AttributeList[0].ContentHtml.Value
-		tableData = parseHTMLTable(htmlValue)
+		if tableData, err = htmlToAccredits(htmlValue, s.Table, expiryParserFrom(ctx)); err != nil {
+			return nil, err
+		}
 	}
 	if len(tableData) == 0 {
 		return nil, fmt.Errorf("no data found")
 	}
+	return tableData, nil
+}
 
+// store 按客户端配置重新计算到期时间和功能项，替换内部缓存并返回副本
+func (c *Client) store(data []Accredit, source string, fetchedAt time.Time) []Accredit {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.Data = make([]Accredit, 0, len(tableData))
-	for k, v := range tableData {
-		c.Data = append(c.Data, Accredit{Sn: k, Time: v})
+	featureCol := c.featureCol
+	c.mu.Unlock()
+
+	data = cloneAccredits(data)
+	for i := range data {
+		data[i].Expires, _ = c.parseExpiry(data[i].Time)
+		if v, ok := featureColumn(data[i].Fields, featureCol); ok {
+			data[i].Features = ParseEntitlements(v)
+		}
 	}
-	return cloneAccredits(c.Data), nil
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Data = data
+	c.source = source
+	c.fetchedAt = fetchedAt
+	return cloneAccredits(c.Data)
 }
 
 func cloneAccredits(data []Accredit) []Accredit {
@@ -358,5 +604,15 @@ func cloneAccredits(data []Accredit) []Accredit {
 	}
 	cloned := make([]Accredit, len(data))
 	copy(cloned, data)
+	for i := range cloned {
+		if cloned[i].Fields != nil {
+			fields := make(map[string]string, len(cloned[i].Fields))
+			for k, v := range cloned[i].Fields {
+				fields[k] = v
+			}
+			cloned[i].Fields = fields
+		}
+		cloned[i].Features = cloned[i].Features.clone()
+	}
 	return cloned
 }
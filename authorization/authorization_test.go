@@ -1,8 +1,12 @@
 package authorization
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
+
+	"github.com/2Kil/tkstar/authorization/authtest"
 )
 
 func TestIsTimeValidDateStaysValidForCurrentDay(t *testing.T) {
@@ -21,3 +25,102 @@ func TestCloneAccreditsReturnsIndependentCopy(t *testing.T) {
 		t.Fatalf("original slice was modified: %+v", original)
 	}
 }
+
+func TestCheckAccreditDetailedReasons(t *testing.T) {
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{
+		{Sn: "OK", Time: "2099-01-01"},
+		{Sn: "OLD", Time: "2000-01-01 00:00:00"},
+		{Sn: "BAD", Time: "someday"},
+	}})
+
+	r := client.CheckAccreditDetailed("OK")
+	if !r.Valid || r.Err != nil || r.Source != "stub" || r.Remaining <= 0 || r.Expires.Year() != 2099 {
+		t.Fatalf("OK result = %+v", r)
+	}
+	for key, want := range map[string]error{"OLD": ErrExpired, "BAD": ErrUnparseableTime, "NONE": ErrNotFound} {
+		if r := client.CheckAccreditDetailed(key); r.Valid || !errors.Is(r.Err, want) {
+			t.Fatalf("%s err = %v, want %v", key, r.Err, want)
+		}
+	}
+
+	down := NewSourceClient(&stubSource{name: "down", err: errors.New("offline")})
+	if r := down.CheckAccreditDetailed("OK"); !errors.Is(r.Err, ErrFetchFailed) {
+		t.Fatalf("fetch err = %v", r.Err)
+	}
+}
+
+func newTestClient(srv *authtest.Server, pwd ...string) *Client {
+	client := NewClient("active/q8tDtnl", pwd...)
+	client.SetEndpoints(Endpoints{ClewmBaseURL: srv.ClewmBaseURL(), CaoliaoEndpoint: srv.CaoliaoEndpoint()})
+	client.SetRetry(RetryPolicy{Attempts: 2, Backoff: time.Millisecond})
+	return client
+}
+
+func TestGetAccreditAgainstFakeServer(t *testing.T) {
+	srv := authtest.NewServer()
+	defer srv.Close()
+	srv.SetPassword("123456")
+	srv.SetTable([]string{"序列号", "到期时间", "客户"}, []string{"A", "2099-01-01", "acme"}, []string{"B", "2000-01-01", "old"})
+
+	client := newTestClient(srv)
+	list, err := client.GetAccredit()
+	if err != nil {
+		t.Fatalf("GetAccredit: %v", err)
+	}
+	if len(list) != 2 || list[0].Fields["客户"] != "acme" {
+		t.Fatalf("list = %+v", list)
+	}
+
+	client = newTestClient(srv, "123456")
+	if list, err = client.GetAccredit2(); err != nil || len(list) != 2 {
+		t.Fatalf("GetAccredit2 = %+v, %v", list, err)
+	}
+	if !client.CheckAccredit("A") || client.CheckAccredit("B") {
+		t.Fatal("unexpected check result")
+	}
+
+	if _, err := newTestClient(srv, "wrong").GetAccredit2(); err == nil {
+		t.Fatal("expected wrong password to fail")
+	}
+}
+
+func TestGetAccreditFailureModes(t *testing.T) {
+	srv := authtest.NewServer()
+	defer srv.Close()
+	srv.SetTable(nil, []string{"A", "2099-01-01"})
+
+	for _, f := range []authtest.Failure{authtest.FailNoJumpURL, authtest.FailNoTable, authtest.FailStatus} {
+		srv.SetFailure(f)
+		if _, err := newTestClient(srv).GetAccredit(); err == nil {
+			t.Fatalf("failure %d: expected error", f)
+		}
+	}
+	for _, f := range []authtest.Failure{authtest.FailMalformedJSON, authtest.FailEmptyResponses, authtest.FailNoTable} {
+		srv.SetFailure(f)
+		if _, err := newTestClient(srv, "").GetAccredit2(); err == nil {
+			t.Fatalf("failure %d: expected error", f)
+		}
+	}
+
+	// 新版接口失败时回退到旧版页面
+	srv.SetFailure(authtest.FailMalformedJSON)
+	if !newTestClient(srv, "pwd").CheckAccredit("A") {
+		t.Fatal("expected fallback to clewm page")
+	}
+}
+
+func TestGetAccreditContextSlowServer(t *testing.T) {
+	srv := authtest.NewServer()
+	defer srv.Close()
+	srv.SetDelay(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := newTestClient(srv).GetAccreditContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("took %v", time.Since(start))
+	}
+}
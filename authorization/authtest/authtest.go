@@ -0,0 +1,237 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-16 10:22:09
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-16 17:45:51
+ * @Description:本地模拟授权服务，供测试使用
+ */
+
+// Package authtest 提供本地模拟的活码授权服务，
+// 模拟旧版活码的 jump_url 页面和新版活码的 batch-requests 接口，
+// 配合 authorization.Client.SetEndpoints 使用，无需访问外网。
+package authtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Failure 模拟的故障类型
+type Failure int
+
+const (
+	FailNone           Failure = iota
+	FailNoJumpURL              // 活码页面缺少 jump_url
+	FailNoTable                // 跳转页面或接口内容中没有表格
+	FailMalformedJSON          // 接口返回非法 JSON
+	FailEmptyResponses         // 接口 responses 为空
+	FailStatus                 // 所有请求返回 500
+)
+
+// Server 本地模拟授权服务
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	header   []string
+	rows     [][]string
+	pwd      string
+	failure  Failure
+	delay    time.Duration
+	date     time.Time
+	requests int
+}
+
+// NewServer 启动模拟服务，使用完毕需调用 Close
+func NewServer() *Server {
+	s := &Server{header: []string{"序列号", "到期时间"}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /clewm/{code...}", s.handleClewm)
+	mux.HandleFunc("GET /table/{code...}", s.handleTable)
+	mux.HandleFunc("POST /batch-requests", s.handleBatch)
+	s.Server = httptest.NewServer(s.wrap(mux))
+	return s
+}
+
+// ClewmBaseURL 旧版活码地址前缀，对应 Endpoints.ClewmBaseURL
+func (s *Server) ClewmBaseURL() string { return s.URL + "/clewm/" }
+
+// CaoliaoEndpoint 新版活码接口地址，对应 Endpoints.CaoliaoEndpoint
+func (s *Server) CaoliaoEndpoint() string { return s.URL + "/batch-requests" }
+
+// SetTable 设置授权表格，header 为空时不输出表头行
+func (s *Server) SetTable(header []string, rows ...[]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = append([]string(nil), header...)
+	s.rows = make([][]string, len(rows))
+	for i, row := range rows {
+		s.rows[i] = append([]string(nil), row...)
+	}
+}
+
+// SetPassword 设置新版活码密码，密码错误时接口不返回表格
+func (s *Server) SetPassword(pwd string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pwd = pwd
+}
+
+// SetFailure 设置模拟故障
+func (s *Server) SetFailure(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failure = f
+}
+
+// SetDelay 设置每个响应的延迟，用于模拟慢速网络
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// SetDate 设置响应 Date 头的时间，零值使用真实时间
+func (s *Server) SetDate(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.date = t
+}
+
+// Requests 返回已收到的请求数
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// wrap 统一处理计数、延迟、Date 头和 FailStatus
+func (s *Server) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		delay, failure, date := s.delay, s.failure, s.date
+		s.mu.Unlock()
+
+		if delay > 0 {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(delay):
+			}
+		}
+		if !date.IsZero() {
+			w.Header().Set("Date", date.UTC().Format(http.TimeFormat))
+		}
+		if failure == FailStatus {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleClewm(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if s.currentFailure() == FailNoJumpURL {
+		fmt.Fprint(w, "<html><body>活码不存在</body></html>")
+		return
+	}
+	jump := s.URL + "/table/" + r.PathValue("code")
+	fmt.Fprintf(w, "<html><head><script>var jump_url=%q;</script></head><body></body></html>", jump)
+}
+
+func (s *Server) handleTable(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html><body>%s</body></html>", s.tableHTML())
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch s.currentFailure() {
+	case FailMalformedJSON:
+		fmt.Fprint(w, `{"responses":[`)
+		return
+	case FailEmptyResponses:
+		fmt.Fprint(w, `{"responses":[]}`)
+		return
+	}
+
+	var payload struct {
+		Requests []struct {
+			Body string `json:"body"`
+		} `json:"requests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || len(payload.Requests) == 0 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	form, _ := url.ParseQuery(payload.Requests[0].Body)
+
+	s.mu.Lock()
+	pwd := s.pwd
+	s.mu.Unlock()
+
+	inner := map[string]any{"code": 403, "data": map[string]any{}}
+	if form.Get("password") == pwd {
+		inner = map[string]any{
+			"code": 200,
+			"data": map[string]any{
+				"qrcode_msg": map[string]any{
+					"qrcode_compontent": []any{
+						map[string]any{
+							"attribute_list": []any{
+								map[string]any{"content_html": map[string]any{"value": s.tableHTML()}},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	innerBody, _ := json.Marshal(inner)
+	json.NewEncoder(w).Encode(map[string]any{
+		"responses": []any{map[string]any{"status": 200, "body": string(innerBody)}},
+	})
+}
+
+// tableHTML 生成当前授权表格的 HTML
+func (s *Server) tableHTML() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failure == FailNoTable {
+		return "<p>暂无内容</p>"
+	}
+
+	var b strings.Builder
+	b.WriteString("<table>")
+	if len(s.header) > 0 {
+		b.WriteString("<tr>")
+		for _, h := range s.header {
+			b.WriteString("<th>" + html.EscapeString(h) + "</th>")
+		}
+		b.WriteString("</tr>")
+	}
+	for _, row := range s.rows {
+		b.WriteString("<tr>")
+		for _, cell := range row {
+			b.WriteString("<td>" + html.EscapeString(cell) + "</td>")
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
+
+func (s *Server) currentFailure() Failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failure
+}
@@ -0,0 +1,147 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-09 09:30:05
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-09 15:02:41
+ * @Description:授权数据离线缓存
+ */
+package authorization
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/2Kil/tkstar/text"
+)
+
+// defaultCacheKey 未设置 Cache.Key 时使用的口令，仅起混淆作用
+const defaultCacheKey = "tkstar/authorization/cache"
+
+// Cache 授权数据的加密离线缓存
+type Cache struct {
+	Path  string        // 缓存文件路径
+	Key   string        // 加密口令，实际密钥由 SHA-256 派生，建议每个产品单独设置
+	TTL   time.Duration // 新鲜期，期内直接使用缓存不访问网络
+	Grace time.Duration // 离线宽限期，过了新鲜期后所有数据源失败时仍信任缓存的时长
+
+	mu sync.Mutex // 保护 Save 和锚点的读改写
+}
+
+// CacheEntry 缓存文件内容
+type CacheEntry struct {
+	FetchedAt time.Time  `json:"fetched_at"` // 拉取时间
+	Source    string     `json:"source"`     // 数据来源
+	Data      []Accredit `json:"data"`
+	// Anchors 防篡改锚点，例如试用安装时间和吊销列表版本；Save 时保留文件中已有的锚点
+	Anchors map[string]string `json:"anchors,omitempty"`
+}
+
+// Fresh 判断缓存在 now 时刻是否仍处于新鲜期
+func (c *Cache) Fresh(e *CacheEntry, now time.Time) bool {
+	return e != nil && c.TTL > 0 && now.Before(e.FetchedAt.Add(c.TTL))
+}
+
+// Usable 判断缓存在 now 时刻是否仍可作为离线兜底（新鲜期 + 宽限期）
+func (c *Cache) Usable(e *CacheEntry, now time.Time) bool {
+	return e != nil && now.Before(e.FetchedAt.Add(c.TTL+c.Grace))
+}
+
+// Load 读取并解密缓存文件
+func (c *Cache) Load() (*CacheEntry, error) {
+	var e CacheEntry
+	if err := readSealedJSON(c.Path, c.aesKey(), &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Save 加密写入缓存文件，e 中没有的锚点沿用文件中的值
+func (c *Cache) Save(e *CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if old, err := c.Load(); err == nil && len(old.Anchors) > 0 {
+		merged := *e
+		merged.Anchors = maps.Clone(old.Anchors)
+		maps.Copy(merged.Anchors, e.Anchors)
+		e = &merged
+	}
+	return writeSealedJSON(c.Path, c.aesKey(), e)
+}
+
+// anchor 读取锚点，缓存文件不存在或无法解密时返回 false
+func (c *Cache) anchor(name string) (string, bool) {
+	e, err := c.Load()
+	if err != nil {
+		return "", false
+	}
+	val, ok := e.Anchors[name]
+	return val, ok
+}
+
+// setAnchor 写入锚点，保留缓存中的授权数据；缓存文件不存在时只写入锚点
+func (c *Cache) setAnchor(name, val string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, err := c.Load()
+	if err != nil {
+		e = &CacheEntry{}
+	}
+	if e.Anchors == nil {
+		e.Anchors = map[string]string{}
+	}
+	e.Anchors[name] = val
+	return writeSealedJSON(c.Path, c.aesKey(), e)
+}
+
+func (c *Cache) aesKey() string {
+	key := c.Key
+	if key == "" {
+		key = defaultCacheKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	return string(sum[:])
+}
+
+// readSealedJSON 读取 AES-GCM 加密的 JSON 文件，内容被篡改时解密失败
+func readSealedJSON(path, key string, v any) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	plain, err := text.TextAesGcmDecrypt(string(raw), key)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal([]byte(plain), v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// writeSealedJSON 以 AES-GCM 加密写入 JSON 文件，先写临时文件再重命名，避免中途失败留下半个文件
+func writeSealedJSON(path, key string, v any) error {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	cipherText, err := text.TextAesGcmEncrypt(string(plain), key)
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(cipherText), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,128 @@
+package authorization
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheSaveLoadIsEncrypted(t *testing.T) {
+	cache := &Cache{Path: filepath.Join(t.TempDir(), "license.cache"), Key: "secret"}
+	entry := &CacheEntry{FetchedAt: time.Now().Truncate(time.Second), Source: "clewm", Data: []Accredit{{Sn: "SN-1", Time: "2099-01-01"}}}
+	if err := cache.Save(entry); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	raw, err := os.ReadFile(cache.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "SN-1") {
+		t.Fatal("cache file stores plain text")
+	}
+
+	got, err := cache.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.Source != "clewm" || !got.FetchedAt.Equal(entry.FetchedAt) || len(got.Data) != 1 || got.Data[0].Sn != "SN-1" {
+		t.Fatalf("loaded = %+v", got)
+	}
+
+	if _, err := (&Cache{Path: cache.Path, Key: "other"}).Load(); err == nil {
+		t.Fatal("expected wrong key to fail")
+	}
+}
+
+func TestCheckAccreditUsesCacheWithinGracePeriod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "license.cache")
+	stale := &CacheEntry{FetchedAt: time.Now().Add(-2 * time.Hour), Source: "clewm", Data: []Accredit{{Sn: "SN-1", Time: "2099-01-01"}}}
+
+	within := &Cache{Path: path, TTL: time.Hour, Grace: 24 * time.Hour}
+	if err := within.Save(stale); err != nil {
+		t.Fatal(err)
+	}
+	client := NewSourceClient(&stubSource{name: "down", err: errors.New("offline")})
+	client.SetCache(within)
+	if !client.CheckAccredit("SN-1") {
+		t.Fatal("expected cached license to be trusted during grace period")
+	}
+
+	client = NewSourceClient(&stubSource{name: "down", err: errors.New("offline")})
+	client.SetCache(&Cache{Path: path, TTL: time.Hour, Grace: time.Minute})
+	if client.CheckAccredit("SN-1") {
+		t.Fatal("expected cache past grace period to be rejected")
+	}
+}
+
+func TestRefreshWritesCache(t *testing.T) {
+	cache := &Cache{Path: filepath.Join(t.TempDir(), "license.cache"), TTL: time.Hour}
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "SN-2", Time: "2099-01-01"}}})
+	client.SetCache(cache)
+	if _, err := client.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := cache.Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if got.Source != "stub" || !cache.Fresh(got, time.Now()) {
+		t.Fatalf("cache entry = %+v", got)
+	}
+}
+
+func TestCheckAccreditRejectsMemoryPastGracePeriod(t *testing.T) {
+	src := &stubSource{name: "stub", data: []Accredit{{Sn: "SN-1", Time: "2099-01-01"}}}
+	client := NewSourceClient(src)
+	client.SetCache(&Cache{Path: filepath.Join(t.TempDir(), "license.cache"), TTL: time.Hour, Grace: 24 * time.Hour})
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	if !client.CheckAccredit("SN-1") {
+		t.Fatal("expected license to be valid after fetch")
+	}
+
+	// 过了新鲜期：重新拉取，失败后在宽限期内继续使用内存数据
+	src.err = errors.New("offline")
+	now = now.Add(2 * time.Hour)
+	if r := client.CheckAccreditDetailed("SN-1"); !r.Valid {
+		t.Fatalf("expected data to be trusted during grace period: %v", r.Err)
+	}
+
+	// 过了宽限期：拒绝内存中的旧数据
+	now = now.Add(24 * time.Hour)
+	r := client.CheckAccreditDetailed("SN-1")
+	if r.Valid || !errors.Is(r.Err, ErrFetchFailed) {
+		t.Fatalf("expected ErrFetchFailed past grace period, got valid=%v err=%v", r.Valid, r.Err)
+	}
+
+	// 数据源恢复后重新有效
+	src.err = nil
+	if !client.CheckAccredit("SN-1") {
+		t.Fatal("expected license to be valid after source recovers")
+	}
+}
+
+func TestCheckAccreditRefetchesStaleMemory(t *testing.T) {
+	src := &stubSource{name: "stub", data: []Accredit{{Sn: "SN-1", Time: "2099-01-01"}}}
+	client := NewSourceClient(src)
+	client.SetCache(&Cache{Path: filepath.Join(t.TempDir(), "license.cache"), TTL: time.Hour, Grace: 24 * time.Hour})
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	if !client.CheckAccredit("SN-1") {
+		t.Fatal("expected license to be valid")
+	}
+	src.data = []Accredit{{Sn: "SN-2", Time: "2099-01-01"}}
+	if !client.CheckAccredit("SN-1") {
+		t.Fatal("expected fresh memory data to be used without refetching")
+	}
+	now = now.Add(2 * time.Hour)
+	if client.CheckAccredit("SN-1") || !client.CheckAccredit("SN-2") {
+		t.Fatal("expected stale memory data to be refetched")
+	}
+}
@@ -0,0 +1,126 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-12 10:40:18
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-12 14:55:30
+ * @Description:授权校验结果
+ */
+package authorization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNotFound        = errors.New("authorization: key not found")
+	ErrExpired         = errors.New("authorization: license expired")
+	ErrUnparseableTime = errors.New("authorization: unparseable expiry time")
+	ErrFetchFailed     = errors.New("authorization: fetch failed")
+)
+
+// Result 授权校验的详细结果
+type Result struct {
+	Key        string        // 查询的序列号
+	Valid      bool          // 是否有效
+	Err        error         // 无效原因，可用 errors.Is 与 ErrNotFound 等比较
+	Entry      Accredit      // 命中的授权条目，多条匹配时按 MatchKind 优先级选出
+	Match      MatchKind     // Entry 的匹配方式
+	Duplicates []Accredit    // 其余匹配行，按优先级排序
+	Expires    time.Time     // 到期时间
+	Perpetual  bool          // 是否永久授权
+	Remaining  time.Duration // 剩余时长，已过期时为 0
+	Source     string        // 授权数据来源
+	FetchedAt  time.Time     // 授权数据拉取时间
+
+	CheckedAt     time.Time // 判断所用的时间，启用 Clock 时可能晚于本地时间
+	ClockTampered bool      // 检测到系统时间回拨，此时 Valid 为 false
+
+	Trial *TrialState // 按试用期判断时的试用状态，此时 Source 为 trial
+}
+
+// String 返回便于展示的结果描述
+func (r *Result) String() string {
+	if r.Valid && r.Trial != nil {
+		return fmt.Sprintf("%s trial, %d days left", r.Key, r.Trial.DaysLeft)
+	}
+	if r.Valid {
+		return fmt.Sprintf("%s valid until %s (%s)", r.Key, r.Expires.Format(time.DateTime), r.Source)
+	}
+	return fmt.Sprintf("%s invalid: %v", r.Key, r.Err)
+}
+
+// CheckAccreditDetailed 检查指定 key 的授权，返回详细结果
+func (c *Client) CheckAccreditDetailed(key string) *Result {
+	return c.CheckAccreditDetailedContext(context.Background(), key)
+}
+
+// CheckAccreditDetailedContext 同 CheckAccreditDetailed，受 ctx 控制
+// 设置了试用期时，序列号未找到或拉取失败会改按试用期判断
+func (c *Client) CheckAccreditDetailedContext(ctx context.Context, key string) *Result {
+	r := c.checkLicense(ctx, key)
+	c.applyTrial(r)
+	c.auditCheck(r)
+	return r
+}
+
+// checkLicense 按授权表判断
+func (c *Client) checkLicense(ctx context.Context, key string) *Result {
+	r := &Result{Key: key}
+	entry, err := c.loadData(ctx)
+	if err != nil {
+		r.Err = fmt.Errorf("%w: %w", ErrFetchFailed, err)
+		return r
+	}
+	r.Source = entry.Source
+	r.FetchedAt = entry.FetchedAt
+
+	c.mu.Lock()
+	clock, groups := c.clock, c.groups
+	c.mu.Unlock()
+
+	matches := matchEntries(entry.Data, key, groups)
+	if len(matches) == 0 {
+		r.Err = ErrNotFound
+		return r
+	}
+	r.Entry, r.Match = matches[0].item, matches[0].kind
+	for _, m := range matches[1:] {
+		r.Duplicates = append(r.Duplicates, m.item)
+	}
+	now := c.timeNow()
+	if clock != nil {
+		var cerr error
+		clock.atLeast(entry.FetchedAt)
+		now, cerr = clock.Now()
+		r.ClockTampered = errors.Is(cerr, ErrClockTampered)
+	}
+
+	r.evaluate(now, c.parseExpiry)
+	c.applyRevocations(r)
+	if r.ClockTampered && r.Valid {
+		r.Valid = false
+		r.Err = ErrClockTampered
+	}
+	return r
+}
+
+// evaluate 根据命中条目的到期时间填充结果
+func (r *Result) evaluate(now time.Time, parse func(string) (time.Time, error)) {
+	r.CheckedAt = now
+	expires, err := parse(r.Entry.Time)
+	if err != nil {
+		r.Err = err
+		return
+	}
+	r.Expires = expires
+	r.Perpetual = expires.Equal(Perpetual)
+	if now.After(expires) {
+		r.Err = ErrExpired
+		return
+	}
+	r.Remaining = expires.Sub(now)
+	r.Valid = true
+}
@@ -0,0 +1,199 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-17 09:36:14
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-17 16:20:47
+ * @Description:防系统时间回拨
+ */
+package authorization
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrClockTampered 检测到系统时间被回拨
+var ErrClockTampered = errors.New("authorization: system clock moved backwards")
+
+// defaultClockKey 未设置 Clock.Key 时使用的口令，仅起混淆作用
+const defaultClockKey = "tkstar/authorization/clock"
+
+// Clock 防回拨时钟
+// 在加密且带完整性校验的文件中记录单调递增的“最后可见时间”，
+// 并可采用数据源响应的 Date 头作为可信时间。
+type Clock struct {
+	Path            string        // 最后可见时间的存储文件，为空时只在内存中记录
+	Key             string        // 加密口令，建议每个产品单独设置
+	Tolerance       time.Duration // 允许的回拨幅度，默认 5 分钟
+	TrustServerTime bool          // 是否采用数据源响应的 Date 头
+
+	mu        sync.Mutex
+	loaded    bool
+	corrupt   bool      // 存储文件无法解密，视为被篡改
+	lastSeen  time.Time // 最后可见时间
+	lastSaved time.Time
+	server    time.Time // 最近一次服务器时间
+	serverAt  time.Time // 收到服务器时间时的本地时间（含单调时钟）
+}
+
+// Now 返回用于授权判断的时间
+// 有可信服务器时间时以服务器时间为准并重新同步最后可见时间：本地时间落后只是时钟不准，不视为篡改，
+// 之前时钟偏快留下的最后可见时间也随之纠正；
+// 否则取本地时间和最后可见时间的较大者，本地时间落后超过容差或存储文件被篡改时返回 ErrClockTampered
+func (c *Clock) Now() (time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+
+	if !c.server.IsZero() {
+		// 单调时钟推算当前服务器时间，不受系统时间修改影响
+		now := c.server.Add(time.Since(c.serverAt))
+		// 纠正篡改标记或偏快的记录时立即保存，其余按分钟节流
+		save := c.corrupt || now.Before(c.lastSaved) || now.Sub(c.lastSaved) >= time.Minute
+		c.lastSeen, c.corrupt = now, false
+		if save {
+			c.save()
+		}
+		return now, nil
+	}
+
+	local := time.Now()
+	tolerance := c.Tolerance
+	if tolerance <= 0 {
+		tolerance = 5 * time.Minute
+	}
+
+	now := local
+	tampered := c.corrupt
+	if !c.lastSeen.IsZero() {
+		if local.Before(c.lastSeen.Add(-tolerance)) {
+			tampered = true
+		}
+		if c.lastSeen.After(now) {
+			now = c.lastSeen
+		}
+	}
+
+	c.lastSeen = now
+	if now.Sub(c.lastSaved) >= time.Minute {
+		c.save()
+	}
+	if tampered {
+		return now, ErrClockTampered
+	}
+	return now, nil
+}
+
+// atLeast 将最后可见时间至少提高到 t
+// 以加密离线缓存的拉取时间兜底，删除存储文件后回拨到上次拉取之前仍能被发现
+func (c *Clock) atLeast(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.load()
+	if t.After(c.lastSeen) {
+		c.lastSeen = t
+	}
+}
+
+// Observe 记录一次可信的服务器时间
+func (c *Clock) Observe(server time.Time) {
+	if server.IsZero() {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.server = server
+	c.serverAt = time.Now()
+}
+
+// Transport 包装 base，从响应 Date 头中采集服务器时间，base 为空时使用 http.DefaultTransport
+func (c *Clock) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &clockTransport{clock: c, base: base}
+}
+
+type clockTransport struct {
+	clock *Clock
+	base  http.RoundTripper
+}
+
+func (t *clockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		if date, perr := http.ParseTime(resp.Header.Get("Date")); perr == nil {
+			t.clock.Observe(date)
+		}
+	}
+	return resp, err
+}
+
+type clockKey struct{}
+
+// withServerClock 返回携带 Clock 的 ctx，数据源的 HTTP 响应经 ObserveServerDate 采集服务器时间
+func withServerClock(ctx context.Context, c *Clock) context.Context {
+	if c == nil || !c.TrustServerTime {
+		return ctx
+	}
+	return context.WithValue(ctx, clockKey{}, c)
+}
+
+// ObserveServerDate 从数据源的 HTTP 响应 Date 头采集服务器时间
+// 内置数据源已自动调用；自定义 Source 在 Fetch 中对响应调用后，Clock.TrustServerTime 同样生效
+func ObserveServerDate(ctx context.Context, resp *http.Response) {
+	c, _ := ctx.Value(clockKey{}).(*Clock)
+	if c == nil || resp == nil {
+		return
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		c.Observe(date)
+	}
+}
+
+// clockState 存储文件内容
+type clockState struct {
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// load 首次使用时读取存储文件，无法解密视为被篡改
+func (c *Clock) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+	if c.Path == "" {
+		return
+	}
+	var st clockState
+	if err := readSealedJSON(c.Path, c.aesKey(), &st); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			c.corrupt = true
+		}
+		return
+	}
+	c.lastSeen = st.LastSeen
+}
+
+func (c *Clock) save() {
+	if c.Path == "" {
+		return
+	}
+	if err := writeSealedJSON(c.Path, c.aesKey(), clockState{LastSeen: c.lastSeen}); err == nil {
+		c.lastSaved = c.lastSeen
+	}
+}
+
+func (c *Clock) aesKey() string {
+	key := c.Key
+	if key == "" {
+		key = defaultClockKey
+	}
+	sum := sha256.Sum256([]byte(key))
+	return string(sum[:])
+}
@@ -0,0 +1,119 @@
+package authorization
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/2Kil/tkstar/authorization/authtest"
+)
+
+func TestClockDetectsRollbackFromStoredTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clock")
+	future := time.Now().Add(time.Hour)
+	seed := &Clock{Path: path}
+	seed.lastSeen = future
+	seed.save()
+
+	clock := &Clock{Path: path}
+	now, err := clock.Now()
+	if !errors.Is(err, ErrClockTampered) {
+		t.Fatalf("err = %v, want tampered", err)
+	}
+	if now.Before(future) {
+		t.Fatalf("now = %v, want at least stored time %v", now, future)
+	}
+
+	if err := os.WriteFile(path, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&Clock{Path: path}).Now(); !errors.Is(err, ErrClockTampered) {
+		t.Fatalf("corrupt file err = %v", err)
+	}
+
+	if _, err := (&Clock{Path: filepath.Join(t.TempDir(), "fresh")}).Now(); err != nil {
+		t.Fatalf("fresh clock err = %v", err)
+	}
+}
+
+// 本地时间落后于服务器时间只是时钟不准：按服务器时间判断，不报告篡改
+func TestCheckAccreditDetailedUsesServerDate(t *testing.T) {
+	srv := authtest.NewServer()
+	defer srv.Close()
+	srv.SetTable(nil, []string{"A", time.Now().Add(30 * time.Minute).Format("2006-01-02 15:04:05")})
+	srv.SetDate(time.Now().Add(2 * time.Hour))
+
+	client := newTestClient(srv)
+	client.SetClock(&Clock{TrustServerTime: true})
+	r := client.CheckAccreditDetailed("A")
+	if r.Valid || r.ClockTampered {
+		t.Fatalf("result = %+v", r)
+	}
+	if !errors.Is(r.Err, ErrExpired) {
+		t.Fatalf("err = %v, want expired by server time", r.Err)
+	}
+
+	srv.SetDate(time.Time{})
+	client = newTestClient(srv)
+	client.SetClock(&Clock{TrustServerTime: true})
+	if r := client.CheckAccreditDetailed("A"); !r.Valid || r.ClockTampered {
+		t.Fatalf("result = %+v", r)
+	}
+}
+
+func TestClockResyncsFromServerTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clock")
+	// 时钟曾经偏快，最后可见时间停留在未来
+	seed := &Clock{Path: path}
+	seed.lastSeen = time.Now().Add(48 * time.Hour)
+	seed.save()
+
+	clock := &Clock{Path: path}
+	if _, err := clock.Now(); !errors.Is(err, ErrClockTampered) {
+		t.Fatalf("err = %v, want tampered before resync", err)
+	}
+
+	// 服务器时间与已校正的本地时间一致，重新同步后恢复正常
+	clock.Observe(time.Now())
+	if _, err := clock.Now(); err != nil {
+		t.Fatalf("err = %v after resync", err)
+	}
+	if _, err := (&Clock{Path: path}).Now(); err != nil {
+		t.Fatalf("err = %v, want resynced time persisted", err)
+	}
+	// 服务器时间分支按分钟节流写入，不在每次校验时重写存储文件
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	clock.Now()
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("state rewritten on every call: %v", err)
+	}
+
+	// 本地时间落后于服务器时间：以服务器时间为准
+	server := time.Now().Add(2 * time.Hour)
+	clock.Observe(server)
+	now, err := clock.Now()
+	if err != nil || now.Before(server) {
+		t.Fatalf("now = %v, %v; want server time %v", now, err, server)
+	}
+}
+
+func TestClockFloorFromCacheSurvivesDeletedState(t *testing.T) {
+	dir := t.TempDir()
+	cache := &Cache{Path: filepath.Join(dir, "license.cache"), TTL: time.Hour, Grace: 24 * time.Hour}
+	fetched := time.Now().Add(3 * time.Hour) // 上次拉取时的本地时间，之后时间被调回
+	if err := cache.Save(&CacheEntry{FetchedAt: fetched, Source: "stub", Data: []Accredit{{Sn: "A", Time: "2099-01-01"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	client := NewSourceClient(&stubSource{name: "down", err: errors.New("offline")})
+	client.SetCache(cache)
+	client.SetClock(&Clock{Path: filepath.Join(dir, "clock-deleted")}) // 存储文件不存在
+	r := client.CheckAccreditDetailed("A")
+	if r.Valid || !r.ClockTampered {
+		t.Fatalf("result = %+v, want rollback before last fetch detected", r)
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-18 10:11:26
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-18 15:47:02
+ * @Description:授权到期时间解析
+ */
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExpiryParser 将授权表中的时间文本解析为到期时间，loc 为没有时区信息时使用的时区
+type ExpiryParser func(val string, loc *time.Location) (time.Time, error)
+
+// Perpetual 永久授权的到期时间
+var Perpetual = time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+
+// perpetualMarkers 表示永久授权的文本（小写）
+var perpetualMarkers = map[string]bool{
+	"永久": true, "永久有效": true, "永不过期": true, "无限期": true, "终身": true, "长期": true,
+	"never": true, "perpetual": true, "permanent": true, "forever": true, "lifetime": true, "unlimited": true,
+	"∞": true,
+}
+
+// expiryReplacer 统一全角符号和中文日期单位，便于套用标准布局
+var expiryReplacer = strings.NewReplacer(
+	"：", ":", "／", "/", "－", "-", "．", ".", "　", " ",
+	"年", "-", "月", "-", "日", " ", "号", " ",
+	"时", ":", "點", ":", "点", ":", "分", ":", "秒", "",
+	"T", " ",
+)
+
+// 带时间的布局，时分秒缺失的部分视为 0
+var expiryTimeLayouts = []string{
+	"2006-1-2 15:04:05",
+	"2006-1-2 15:04",
+	"2006-1-2 15",
+}
+
+// reExpiryZone 时间末尾的时区偏移
+var reExpiryZone = regexp.MustCompile(`^(.*?) ?(Z|[+-]\d{2}:?\d{2})$`)
+
+// ParseExpiry 默认的到期时间解析器
+// 支持：2006-01-02、2006/1/2、2006.1.2、20060102、2006年1月2日，以及附带的 15:04[:05[.000]]
+// 和 Z、+08:00、+0800 时区偏移（带时区时忽略 loc）；RFC3339；10 位秒级和 13 位毫秒级 Unix 时间戳；
+// 永久/never 等永久授权标记（返回 Perpetual）。只有日期时视为当天结束。
+func ParseExpiry(val string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	raw := strings.TrimSpace(val)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("%w: empty", ErrUnparseableTime)
+	}
+	if perpetualMarkers[strings.ToLower(raw)] {
+		return Perpetual, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t, nil
+	}
+	if isDigits(raw) {
+		switch len(raw) {
+		case 10:
+			sec, _ := strconv.ParseInt(raw, 10, 64)
+			return time.Unix(sec, 0).In(loc), nil
+		case 13:
+			ms, _ := strconv.ParseInt(raw, 10, 64)
+			return time.UnixMilli(ms).In(loc), nil
+		case 8:
+			if t, err := time.ParseInLocation("20060102", raw, loc); err == nil {
+				return endOfDay(t), nil
+			}
+		}
+	}
+
+	s := strings.Join(strings.Fields(expiryReplacer.Replace(raw)), " ")
+	date, clock, hasClock := strings.Cut(s, " ")
+	date = strings.TrimRight(strings.NewReplacer("/", "-", ".", "-").Replace(date), "-")
+	if !hasClock {
+		if t, err := time.ParseInLocation("2006-1-2", date, loc); err == nil {
+			return endOfDay(t), nil
+		}
+		return time.Time{}, fmt.Errorf("%w: %q", ErrUnparseableTime, val)
+	}
+
+	// 时间部分可带小数秒（解析时自动识别）和 Z、+08:00、+0800 形式的时区偏移
+	suffix := ""
+	if m := reExpiryZone.FindStringSubmatch(clock); m != nil {
+		clock, suffix = m[1], " -0700"
+		if m[2] == "Z" {
+			m[2] = "+0000"
+		}
+		clock += " " + strings.Replace(m[2], ":", "", 1)
+	}
+	s = date + " " + strings.TrimRight(clock, ": ")
+	for _, layout := range expiryTimeLayouts {
+		if t, err := time.ParseInLocation(layout+suffix, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q", ErrUnparseableTime, val)
+}
+
+// parseExpiry 使用默认解析器和本地时区解析
+func parseExpiry(val string) (time.Time, error) {
+	return ParseExpiry(val, time.Local)
+}
+
+// expiryFunc 绑定了解析器和时区的到期时间解析函数
+type expiryFunc func(val string) (time.Time, error)
+
+func (f expiryFunc) orDefault() expiryFunc {
+	if f == nil {
+		return parseExpiry
+	}
+	return f
+}
+
+type expiryKey struct{}
+
+// withExpiryParser 让数据源按客户端的 SetExpiryParser 和 SetLocation 识别表头和解析时间
+func withExpiryParser(ctx context.Context, parse expiryFunc) context.Context {
+	return context.WithValue(ctx, expiryKey{}, parse)
+}
+
+// expiryParserFrom 返回 ctx 中客户端的解析函数，没有时使用默认解析器
+func expiryParserFrom(ctx context.Context) expiryFunc {
+	parse, _ := ctx.Value(expiryKey{}).(expiryFunc)
+	return parse.orDefault()
+}
+
+// endOfDay 返回当天最后一刻
+func endOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 23, 59, 59, int(time.Second-time.Nanosecond), t.Location())
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
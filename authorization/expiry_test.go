@@ -0,0 +1,76 @@
+package authorization
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseExpiryFormats(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	endOf0107 := time.Date(2026, 1, 7, 23, 59, 59, 999999999, shanghai)
+	cases := map[string]time.Time{
+		"2026-01-07":                      endOf0107,
+		"2026/1/7":                        endOf0107,
+		"2026.1.7":                        endOf0107,
+		"20260107":                        endOf0107,
+		"2026年01月07日":                     endOf0107,
+		" 2026年1月7号 ":                     endOf0107,
+		"2026-01-07 15:04:05":             time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"2026/01/07 15:04":                time.Date(2026, 1, 7, 15, 4, 0, 0, shanghai),
+		"2026年1月7日 15时04分":                time.Date(2026, 1, 7, 15, 4, 0, 0, shanghai),
+		"2026-01-07T15:04:05+08:00":       time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"2026-01-07T07:04:05Z":            time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"1767769445":                      time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"1767769445000":                   time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"2026-01-07 15:04:05.250":         time.Date(2026, 1, 7, 15, 4, 5, 250e6, shanghai),
+		"2026.01.07 15:04:05.5":           time.Date(2026, 1, 7, 15, 4, 5, 500e6, shanghai),
+		"2026-01-07T15:04:05+0800":        time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"2026-01-07 15:04:05 +0800":       time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"2026-01-07 07:04:05Z":            time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"2026/01/07 10:04 +03:00":         time.Date(2026, 1, 7, 15, 4, 0, 0, shanghai),
+		"2026-01-07 00:04:05-0700":        time.Date(2026, 1, 7, 15, 4, 5, 0, shanghai),
+		"2026-01-07T15:04:05.123456+0800": time.Date(2026, 1, 7, 15, 4, 5, 123456e3, shanghai),
+		"永久":                              Perpetual,
+		"Never":                           Perpetual,
+	}
+	for in, want := range cases {
+		got, err := ParseExpiry(in, shanghai)
+		if err != nil {
+			t.Errorf("ParseExpiry(%q) error: %v", in, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("ParseExpiry(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	for _, in := range []string{"", "someday", "2026-13-01", "12345", "2026-01-07 15:04:05 +08", "2026-01-07 25:00 +0800"} {
+		if _, err := ParseExpiry(in, shanghai); !errors.Is(err, ErrUnparseableTime) {
+			t.Errorf("ParseExpiry(%q) err = %v", in, err)
+		}
+	}
+}
+
+func TestClientLocationAndCustomParser(t *testing.T) {
+	// 北京时间今天结束的授权，在 UTC 时区解析时仍应以北京时间为准
+	shanghai := time.FixedZone("CST", 8*3600)
+	today := time.Now().In(shanghai).Format("2006-01-02")
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "A", Time: today}, {Sn: "B", Time: "forever"}}})
+	client.SetLocation(shanghai)
+
+	r := client.CheckAccreditDetailed("A")
+	if !r.Valid || r.Expires.Location() != shanghai {
+		t.Fatalf("result = %+v", r)
+	}
+	if r := client.CheckAccreditDetailed("B"); !r.Valid || !r.Perpetual {
+		t.Fatalf("perpetual result = %+v", r)
+	}
+
+	client.SetExpiryParser(func(val string, loc *time.Location) (time.Time, error) {
+		return time.Time{}, ErrUnparseableTime
+	})
+	if client.CheckAccredit("A") {
+		t.Fatal("custom parser ignored")
+	}
+}
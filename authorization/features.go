@@ -0,0 +1,131 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-19 09:52:40
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-19 14:18:33
+ * @Description:授权功能项
+ */
+package authorization
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultFeatureColumns 未指定功能列时按顺序查找的表头名（不区分大小写）
+var defaultFeatureColumns = []string{"features", "feature", "entitlements", "功能", "权限", "授权功能"}
+
+// Entitlements 授权包含的功能项，键为小写功能名，开关型功能的值为空
+// 例如 "export, proxy, max_threads=8"
+type Entitlements map[string]string
+
+// ParseEntitlements 解析功能列表，分隔符支持逗号、分号、竖线、顿号和换行，限额写作 name=value（等号两侧可有空格）
+func ParseEntitlements(s string) Entitlements {
+	e := Entitlements{}
+	items := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ';' || r == '|' || r == '，' || r == '；' || r == '、' || r == '\n' || r == '\r'
+	})
+	for _, item := range items {
+		name, value, _ := strings.Cut(item, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		e[name] = strings.TrimSpace(value)
+	}
+	return e
+}
+
+// Has 判断是否包含功能项
+func (e Entitlements) Has(name string) bool {
+	_, ok := e[strings.ToLower(name)]
+	return ok
+}
+
+// Value 返回功能项的值，不存在或开关型功能返回空字符串
+func (e Entitlements) Value(name string) string {
+	return e[strings.ToLower(name)]
+}
+
+// Limit 返回整数型限额，不存在或不是整数时 ok 为 false
+func (e Entitlements) Limit(name string) (int, bool) {
+	v, ok := e[strings.ToLower(name)]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// String 按名称排序输出，格式与 ParseEntitlements 的输入一致
+func (e Entitlements) String() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		if v := e[name]; v != "" {
+			names[i] = name + "=" + v
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+func (e Entitlements) clone() Entitlements {
+	if e == nil {
+		return nil
+	}
+	c := make(Entitlements, len(e))
+	for k, v := range e {
+		c[k] = v
+	}
+	return c
+}
+
+// Entitlements 返回签名令牌中的功能项
+func (l *License) Entitlements() Entitlements {
+	return ParseEntitlements(strings.Join(l.Features, ","))
+}
+
+// featureColumn 在条目的 Fields 中查找功能列
+func featureColumn(fields map[string]string, column string) (string, bool) {
+	if column != "" {
+		v, ok := fields[column]
+		return v, ok
+	}
+	for _, name := range defaultFeatureColumns {
+		for k, v := range fields {
+			if strings.EqualFold(strings.TrimSpace(k), name) {
+				return v, true
+			}
+		}
+	}
+	return "", false
+}
+
+// HasFeature 判断序列号的授权有效且包含功能项
+func (c *Client) HasFeature(sn, name string) bool {
+	r := c.CheckAccreditDetailed(sn)
+	return r.Valid && r.Entry.Features.Has(name)
+}
+
+// Limit 返回序列号授权中的整数型限额，授权无效或没有该项时 ok 为 false
+func (c *Client) Limit(sn, name string) (int, bool) {
+	r := c.CheckAccreditDetailed(sn)
+	if !r.Valid {
+		return 0, false
+	}
+	return r.Entry.Features.Limit(name)
+}
+
+// SetFeatureColumn 指定授权表中的功能列表头名，为空时按 features、功能、权限等常见名称查找
+func (c *Client) SetFeatureColumn(column string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.featureCol = column
+}
@@ -0,0 +1,72 @@
+package authorization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/2Kil/tkstar/authorization/authtest"
+)
+
+func TestParseEntitlements(t *testing.T) {
+	e := ParseEntitlements(" Export， proxy;max_threads = 8 | 导出、quota = x\nlog ")
+	for _, name := range []string{"export", "EXPORT", "proxy", "导出", "max_threads", "quota", "log"} {
+		if !e.Has(name) {
+			t.Errorf("missing %q in %v", name, e)
+		}
+	}
+	if len(e) != 6 || e.Has("8") || e.Has("x") {
+		t.Errorf("entitlements = %v", e)
+	}
+	if n, ok := e.Limit("MAX_THREADS"); !ok || n != 8 {
+		t.Errorf("Limit = %d, %v", n, ok)
+	}
+	if v := e.Value("quota"); v != "x" {
+		t.Errorf("Value(quota) = %q", v)
+	}
+	if _, ok := e.Limit("quota"); ok {
+		t.Error("non-integer limit accepted")
+	}
+	if _, ok := e.Limit("missing"); ok {
+		t.Error("missing limit accepted")
+	}
+	if got := ParseEntitlements(e.String()).String(); got != e.String() {
+		t.Errorf("round trip = %q, want %q", got, e.String())
+	}
+
+	lic := License{Features: []string{"export", "max_threads=4"}}
+	if n, ok := lic.Entitlements().Limit("max_threads"); !lic.Entitlements().Has("export") || !ok || n != 4 {
+		t.Errorf("token entitlements = %v", lic.Entitlements())
+	}
+}
+
+func TestClientFeatures(t *testing.T) {
+	srv := authtest.NewServer()
+	defer srv.Close()
+	future := time.Now().AddDate(0, 1, 0).Format("2006-01-02")
+	past := time.Now().AddDate(0, -1, 0).Format("2006-01-02")
+	srv.SetTable([]string{"序列号", "到期时间", "功能"},
+		[]string{"A", future, "export,max_threads=8"},
+		[]string{"B", past, "export"},
+	)
+	client := newTestClient(srv)
+
+	if !client.HasFeature("A", "export") || client.HasFeature("A", "proxy") {
+		t.Fatal("HasFeature mismatch for A")
+	}
+	if n, ok := client.Limit("A", "max_threads"); !ok || n != 8 {
+		t.Fatalf("Limit = %d, %v", n, ok)
+	}
+	if client.HasFeature("B", "export") {
+		t.Fatal("expired license grants feature")
+	}
+	if client.HasFeature("C", "export") {
+		t.Fatal("unknown license grants feature")
+	}
+
+	srv.SetTable([]string{"sn", "time", "plan"}, []string{"A", future, "proxy"})
+	client = newTestClient(srv)
+	client.SetFeatureColumn("plan")
+	if !client.HasFeature("A", "proxy") || client.HasFeature("A", "export") {
+		t.Fatal("custom feature column ignored")
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-20 10:05:12
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-20 16:21:48
+ * @Description:合并并发拉取与最小刷新间隔
+ */
+package authorization
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRefreshThrottled 距上次拉取不足最小刷新间隔且上次拉取失败
+var ErrRefreshThrottled = errors.New("authorization: refresh throttled")
+
+// DefaultMinRefreshInterval NewClient 默认的最小刷新间隔
+const DefaultMinRefreshInterval = 10 * time.Second
+
+// fetchCall 一次进行中的拉取，等待者共享其结果
+type fetchCall struct {
+	done  chan struct{}
+	entry *CacheEntry
+	err   error
+}
+
+// SetMinRefreshInterval 设置两次访问数据源的最小间隔，间隔内的刷新直接返回上次结果，0 表示不限制
+func (c *Client) SetMinRefreshInterval(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.minRefresh = d
+}
+
+// refresh 拉取数据源；同一客户端同时只有一次拉取在进行，并发调用等待并共享其结果
+func (c *Client) refresh(ctx context.Context) (*CacheEntry, error) {
+	for {
+		c.mu.Lock()
+		if call := c.inflight; call != nil {
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			// 发起者被取消时由当前调用者重新发起
+			if isContextErr(call.err) && ctx.Err() == nil {
+				continue
+			}
+			return call.result()
+		}
+		if ok, entry, err := c.throttled(); ok {
+			c.mu.Unlock()
+			return entry, err
+		}
+		call := &fetchCall{done: make(chan struct{})}
+		c.inflight = call
+		c.mu.Unlock()
+		return c.doFetch(ctx, call)
+	}
+}
+
+// doFetch 执行一次拉取并唤醒等待者；数据源 panic 时等待者收到错误，panic 继续向上传递
+func (c *Client) doFetch(ctx context.Context, call *fetchCall) (*CacheEntry, error) {
+	call.err = fmt.Errorf("%w: source panicked", ErrFetchFailed)
+	defer func() {
+		c.mu.Lock()
+		c.inflight = nil
+		if !isContextErr(call.err) {
+			c.lastAttempt, c.lastErr = time.Now(), call.err
+		}
+		c.mu.Unlock()
+		close(call.done)
+	}()
+	call.entry, call.err = c.fetchSources(ctx)
+	return call.entry, call.err
+}
+
+// throttled 判断是否处于最小刷新间隔内，是则返回上次的结果；调用时需持有 c.mu
+func (c *Client) throttled() (bool, *CacheEntry, error) {
+	if c.minRefresh <= 0 || c.lastAttempt.IsZero() || time.Since(c.lastAttempt) >= c.minRefresh {
+		return false, nil, nil
+	}
+	if c.lastErr != nil {
+		return true, nil, fmt.Errorf("%w: %w", ErrRefreshThrottled, c.lastErr)
+	}
+	if len(c.Data) == 0 {
+		return false, nil, nil
+	}
+	return true, &CacheEntry{FetchedAt: c.fetchedAt, Source: c.source, Data: cloneAccredits(c.Data)}, nil
+}
+
+// result 返回结果副本，避免等待者之间共享切片
+func (f *fetchCall) result() (*CacheEntry, error) {
+	if f.entry == nil {
+		return nil, f.err
+	}
+	e := *f.entry
+	e.Data = cloneAccredits(e.Data)
+	return &e, f.err
+}
+
+func isContextErr(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
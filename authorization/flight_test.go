@@ -0,0 +1,157 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowSource 统计调用次数，每次拉取耗时 delay
+type slowSource struct {
+	calls atomic.Int32
+	delay time.Duration
+	err   error
+}
+
+func (s *slowSource) Name() string { return "slow" }
+
+func (s *slowSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	s.calls.Add(1)
+	if err := sleepContext(ctx, s.delay); err != nil {
+		return nil, err
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []Accredit{{Sn: "A", Time: "2099-01-01"}}, nil
+}
+
+func TestConcurrentChecksShareOneFetch(t *testing.T) {
+	src := &slowSource{delay: 50 * time.Millisecond}
+	client := NewSourceClient(src)
+
+	var wg sync.WaitGroup
+	var valid atomic.Int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if client.CheckAccredit("A") {
+				valid.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := src.calls.Load(); n != 1 {
+		t.Fatalf("fetch calls = %d, want 1", n)
+	}
+	if valid.Load() != 20 {
+		t.Fatalf("valid = %d, want 20", valid.Load())
+	}
+}
+
+func TestCanceledLeaderDoesNotFailWaiters(t *testing.T) {
+	src := &slowSource{delay: 50 * time.Millisecond}
+	client := NewSourceClient(src)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.RefreshContext(ctx)
+		leaderErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	waiterErr := make(chan error, 1)
+	go func() {
+		_, err := client.Refresh()
+		waiterErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Fatalf("leader err = %v", err)
+	}
+	if err := <-waiterErr; err != nil {
+		t.Fatalf("waiter err = %v", err)
+	}
+}
+
+// panicSource 拉取时等待 release 后 panic
+type panicSource struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *panicSource) Name() string { return "panic" }
+
+func (s *panicSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	close(s.started)
+	<-s.release
+	panic("boom")
+}
+
+func TestPanickingSourceReleasesWaiters(t *testing.T) {
+	src := &panicSource{started: make(chan struct{}), release: make(chan struct{})}
+	client := NewSourceClient(src)
+
+	recovered := make(chan any, 1)
+	go func() {
+		defer func() { recovered <- recover() }()
+		client.Refresh()
+	}()
+	<-src.started
+	waiterErr := make(chan error, 1)
+	go func() {
+		_, err := client.Refresh()
+		waiterErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(src.release)
+
+	if r := <-recovered; r == nil {
+		t.Fatal("panic not propagated to the caller")
+	}
+	select {
+	case err := <-waiterErr:
+		if !errors.Is(err, ErrFetchFailed) {
+			t.Fatalf("waiter err = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter blocked after source panic")
+	}
+}
+
+func TestMinRefreshInterval(t *testing.T) {
+	src := &slowSource{}
+	client := NewSourceClient(src)
+	client.SetMinRefreshInterval(time.Hour)
+	for i := 0; i < 3; i++ {
+		if data, err := client.Refresh(); err != nil || len(data) != 1 {
+			t.Fatalf("Refresh = %v, %v", data, err)
+		}
+	}
+	if n := src.calls.Load(); n != 1 {
+		t.Fatalf("fetch calls = %d, want 1", n)
+	}
+
+	failing := &slowSource{err: errors.New("boom")}
+	client = NewSourceClient(failing)
+	client.SetMinRefreshInterval(time.Hour)
+	client.Refresh()
+	if _, err := client.Refresh(); !errors.Is(err, ErrRefreshThrottled) {
+		t.Fatalf("err = %v, want throttled", err)
+	}
+	if n := failing.calls.Load(); n != 1 {
+		t.Fatalf("failing fetch calls = %d, want 1", n)
+	}
+
+	client.SetMinRefreshInterval(0)
+	client.Refresh()
+	if n := failing.calls.Load(); n != 2 {
+		t.Fatalf("fetch calls after disabling = %d, want 2", n)
+	}
+}
@@ -0,0 +1,373 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-15 09:48:20
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-15 18:12:36
+ * @Description:HTML 表格解析
+ */
+package authorization
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+// ErrTableNotFound 页面中没有符合条件的表格
+var ErrTableNotFound = errors.New("authorization: table not found")
+
+// HTMLTable 从页面中提取的表格
+type HTMLTable struct {
+	Index     int        // 在文档中的顺序（按 <table> 出现位置，含嵌套表格）
+	ID        string     // id 属性
+	Class     string     // class 属性
+	Rows      [][]string // 单元格文本，已解码实体并合并空白，colspan/rowspan 展开为重复值
+	HasHeader bool       // 首行是否全部为 <th>
+}
+
+// TableSelector 选择页面中的表格，优先级 ID > Header > Index
+type TableSelector struct {
+	Index  int    // 第几个表格，从 0 开始
+	ID     string // 按 id 属性选择
+	Header string // 选择首行包含该文本（不区分大小写）的第一个表格
+	// HasHeader 首行为表头；不设置时首行为 <th> 或序列号、时间列是常见表头名（如 序列号、到期时间）才视为表头
+	HasHeader bool
+}
+
+// Select 从 tables 中选出符合条件的表格
+func (s TableSelector) Select(tables []*HTMLTable) (*HTMLTable, error) {
+	switch {
+	case s.ID != "":
+		for _, t := range tables {
+			if t.ID == s.ID {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: id %q", ErrTableNotFound, s.ID)
+	case s.Header != "":
+		want := strings.ToLower(strings.TrimSpace(s.Header))
+		for _, t := range tables {
+			if len(t.Rows) == 0 {
+				continue
+			}
+			for _, cell := range t.Rows[0] {
+				if strings.Contains(strings.ToLower(cell), want) {
+					return t, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%w: header %q", ErrTableNotFound, s.Header)
+	default:
+		if s.Index < 0 || s.Index >= len(tables) {
+			return nil, fmt.Errorf("%w: index %d", ErrTableNotFound, s.Index)
+		}
+		return tables[s.Index], nil
+	}
+}
+
+// ParseHTMLTables 提取页面中的全部表格，按 <table> 出现顺序返回
+func ParseHTMLTables(doc string) []*HTMLTable {
+	var tables []*HTMLTable
+	var stack []*tableBuilder
+
+	for _, tok := range tokenizeHTML(doc) {
+		var top *tableBuilder
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		switch tok.kind {
+		case tokText:
+			if top != nil && top.inCell {
+				top.cell.WriteString(tok.text)
+			}
+		case tokStart:
+			switch tok.name {
+			case "table":
+				t := &HTMLTable{Index: len(tables), ID: tok.attrs["id"], Class: tok.attrs["class"]}
+				tables = append(tables, t)
+				stack = append(stack, &tableBuilder{table: t, spans: map[int]*rowSpan{}})
+			case "tr":
+				if top != nil {
+					top.finishRow()
+					top.inRow = true
+				}
+			case "td", "th":
+				if top != nil {
+					top.startCell(tok.name == "th", spanAttr(tok.attrs["colspan"]), spanAttr(tok.attrs["rowspan"]))
+				}
+			case "br", "p", "div", "li":
+				if top != nil && top.inCell {
+					top.cell.WriteByte(' ')
+				}
+			}
+		case tokEnd:
+			if top == nil {
+				continue
+			}
+			switch tok.name {
+			case "table":
+				top.finishRow()
+				stack = stack[:len(stack)-1]
+			case "tr":
+				top.finishRow()
+			case "td", "th":
+				top.finishCell()
+			case "p", "div", "li":
+				if top.inCell {
+					top.cell.WriteByte(' ')
+				}
+			}
+		}
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].finishRow()
+	}
+	return tables
+}
+
+// rowSpan 跨行单元格在后续行中的占位
+type rowSpan struct {
+	value string
+	left  int
+}
+
+// tableBuilder 逐个 token 构建表格
+type tableBuilder struct {
+	table   *HTMLTable
+	row     []string
+	rowTH   bool // 当前行是否全部为 <th>
+	inRow   bool
+	cell    strings.Builder
+	inCell  bool
+	cellTH  bool
+	colspan int
+	rowspan int
+	spans   map[int]*rowSpan
+}
+
+func (b *tableBuilder) startCell(th bool, colspan, rowspan int) {
+	b.finishCell()
+	if !b.inRow {
+		b.finishRow()
+		b.inRow = true
+	}
+	b.fillSpans()
+	if len(b.row) == 0 {
+		b.rowTH = true
+	}
+	b.inCell, b.cellTH = true, th
+	b.colspan, b.rowspan = colspan, rowspan
+	b.cell.Reset()
+}
+
+func (b *tableBuilder) finishCell() {
+	if !b.inCell {
+		return
+	}
+	value := strings.Join(strings.Fields(b.cell.String()), " ")
+	b.rowTH = b.rowTH && b.cellTH
+	for i := 0; i < b.colspan; i++ {
+		if b.rowspan > 1 {
+			b.spans[len(b.row)] = &rowSpan{value: value, left: b.rowspan - 1}
+		}
+		b.row = append(b.row, value)
+	}
+	b.inCell = false
+	b.fillSpans()
+}
+
+// fillSpans 在当前列位置补上上方行 rowspan 延伸下来的单元格
+func (b *tableBuilder) fillSpans() {
+	for {
+		sp, ok := b.spans[len(b.row)]
+		if !ok || sp.left <= 0 {
+			return
+		}
+		b.row = append(b.row, sp.value)
+		if sp.left--; sp.left == 0 {
+			delete(b.spans, len(b.row)-1)
+		}
+	}
+}
+
+func (b *tableBuilder) finishRow() {
+	b.finishCell()
+	if len(b.row) > 0 {
+		if len(b.table.Rows) == 0 {
+			b.table.HasHeader = b.rowTH
+		}
+		b.table.Rows = append(b.table.Rows, b.row)
+	}
+	b.row, b.rowTH, b.inRow = nil, false, false
+}
+
+// spanAttr 解析 colspan/rowspan，非法值按 1 处理，并限制上限避免异常页面撑爆内存
+func spanAttr(v string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n < 1 {
+		return 1
+	}
+	if n > 100 {
+		return 100
+	}
+	return n
+}
+
+const (
+	tokText = iota
+	tokStart
+	tokEnd
+)
+
+// htmlToken 简化的 HTML 词法单元，注释、doctype 等直接丢弃
+type htmlToken struct {
+	kind  int
+	name  string            // 小写标签名
+	attrs map[string]string // 属性，值已解码
+	text  string            // 文本，实体已解码
+}
+
+// rawTextTags 内容不按 HTML 解析的标签
+var rawTextTags = map[string]bool{"script": true, "style": true, "textarea": true, "title": true}
+
+// tokenizeHTML 将 HTML 切分为文本、开始标签和结束标签
+func tokenizeHTML(s string) []htmlToken {
+	var toks []htmlToken
+	for i := 0; i < len(s); {
+		if s[i] != '<' {
+			j := strings.IndexByte(s[i:], '<')
+			if j < 0 {
+				j = len(s) - i
+			}
+			toks = append(toks, htmlToken{kind: tokText, text: html.UnescapeString(s[i : i+j])})
+			i += j
+			continue
+		}
+
+		rest := s[i:]
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				return toks
+			}
+			i += 4 + end + 3
+		case strings.HasPrefix(rest, "<!"), strings.HasPrefix(rest, "<?"):
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return toks
+			}
+			i += end + 1
+		case strings.HasPrefix(rest, "</") && len(rest) > 2 && isASCIILetter(rest[2]):
+			name, _ := readTagName(rest[2:])
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				return toks
+			}
+			toks = append(toks, htmlToken{kind: tokEnd, name: name})
+			i += end + 1
+		case len(rest) > 1 && isASCIILetter(rest[1]):
+			tok, n := readStartTag(rest)
+			toks = append(toks, tok)
+			i += n
+			if rawTextTags[tok.name] {
+				end := indexFold(s[i:], "</"+tok.name)
+				if end < 0 {
+					return toks
+				}
+				i += end
+			}
+		default:
+			toks = append(toks, htmlToken{kind: tokText, text: "<"})
+			i++
+		}
+	}
+	return toks
+}
+
+// readStartTag 解析开始标签，返回 token 和消耗的字节数
+func readStartTag(s string) (htmlToken, int) {
+	name, n := readTagName(s[1:])
+	tok := htmlToken{kind: tokStart, name: name, attrs: map[string]string{}}
+	i := 1 + n
+	for i < len(s) {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			break
+		}
+		if s[i] == '>' {
+			return tok, i + 1
+		}
+		if s[i] == '/' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && s[i] != '/' {
+			i++
+		}
+		key := strings.ToLower(s[start:i])
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		val := ""
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				q := s[i]
+				end := strings.IndexByte(s[i+1:], q)
+				if end < 0 {
+					val, i = s[i+1:], len(s)
+				} else {
+					val, i = s[i+1:i+1+end], i+1+end+1
+				}
+			} else {
+				start := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				val = s[start:i]
+			}
+		}
+		if key != "" {
+			if _, ok := tok.attrs[key]; !ok {
+				tok.attrs[key] = html.UnescapeString(val)
+			}
+		}
+	}
+	return tok, len(s)
+}
+
+func readTagName(s string) (string, int) {
+	i := 0
+	for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' && s[i] != '/' {
+		i++
+	}
+	return strings.ToLower(s[:i]), i
+}
+
+func isASCIILetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexFold 不区分大小写查找 substr
+func indexFold(s, substr string) int {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,69 @@
+package authorization
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testLicensePage = `<!DOCTYPE html>
+<html><head><title>a <table> in title</title>
+<script>var s = "<table><tr><td>x</td></tr></table>";</script></head>
+<body>
+<table id="nav"><tr><td>首页</td><td>关于</td></tr></table>
+<!-- <table><tr><td>commented</td></tr></table> -->
+<table id="licenses" class="list">
+  <thead><tr><th>序列号</th><th>到期时间</th><th colspan="2">备注</th></tr></thead>
+  <tbody>
+  <tr><td>A&amp;B</td><td>2099-01-01&nbsp;</td><td rowspan="2">x</td><td>1</td></tr>
+  <tr><td>  C
+      D </td><td>2099-02-02<br>12:00:00</td><td>2</td></tr>
+  <tr><td>E<table><tr><td>inner</td></tr></table></td><td>2099-03-03</td></tr>
+  </tbody>
+</table>
+</body></html>`
+
+func TestParseHTMLTables(t *testing.T) {
+	tables := ParseHTMLTables(testLicensePage)
+	if len(tables) != 3 {
+		t.Fatalf("tables = %d, want 3", len(tables))
+	}
+
+	lic := tables[1]
+	if lic.ID != "licenses" || lic.Class != "list" || !lic.HasHeader {
+		t.Fatalf("table = %+v", lic)
+	}
+	want := [][]string{
+		{"序列号", "到期时间", "备注", "备注"},
+		{"A&B", "2099-01-01", "x", "1"},
+		{"C D", "2099-02-02 12:00:00", "x", "2"},
+		{"E", "2099-03-03"},
+	}
+	if !reflect.DeepEqual(lic.Rows, want) {
+		t.Fatalf("rows = %q", lic.Rows)
+	}
+	if tables[2].Rows[0][0] != "inner" {
+		t.Fatalf("nested = %q", tables[2].Rows)
+	}
+}
+
+func TestTableSelector(t *testing.T) {
+	tables := ParseHTMLTables(testLicensePage)
+	for _, sel := range []TableSelector{{Index: 1}, {ID: "licenses"}, {Header: "到期"}} {
+		got, err := sel.Select(tables)
+		if err != nil || got.ID != "licenses" {
+			t.Fatalf("%+v selected %+v, %v", sel, got, err)
+		}
+	}
+	if _, err := (TableSelector{ID: "missing"}).Select(tables); !errors.Is(err, ErrTableNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+
+	data, err := htmlToAccredits(testLicensePage, TableSelector{ID: "licenses"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3 || data[0].Sn != "A&B" || data[0].Fields["备注"] != "x" || data[0].Fields["备注_2"] != "1" {
+		t.Fatalf("data = %+v", data)
+	}
+}
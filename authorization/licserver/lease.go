@@ -0,0 +1,141 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-22 09:35:50
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-22 16:08:27
+ * @Description:并发席位租约
+ */
+package licserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultLeaseTTL 未设置 Options.LeaseTTL 时租约的有效期
+const DefaultLeaseTTL = 2 * time.Minute
+
+var (
+	ErrSeatsExhausted = errors.New("licserver: no free seats")
+	ErrLeaseNotFound  = errors.New("licserver: lease not found")
+)
+
+// Lease 一台机器占用的席位，过期未续约自动释放
+type Lease struct {
+	ID         string    `json:"lease_id"`
+	Sn         string    `json:"sn"`
+	Machine    string    `json:"machine"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// leaseTable 内存中的租约表，服务重启后客户端通过续约失败重新申请
+type leaseTable struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	byID map[string]*Lease
+}
+
+func newLeaseTable(ttl time.Duration) *leaseTable {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+	return &leaseTable{ttl: ttl, byID: map[string]*Lease{}}
+}
+
+// acquire 为 (sn, machine) 申请席位，同一机器重复申请时续约原租约；seats 为 0 表示不限
+func (t *leaseTable) acquire(sn, machine string, seats int, now time.Time) (Lease, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(now)
+
+	used := 0
+	for _, l := range t.byID {
+		if l.Sn != sn {
+			continue
+		}
+		if l.Machine == machine {
+			l.ExpiresAt = now.Add(t.ttl)
+			return *l, nil
+		}
+		used++
+	}
+	if seats > 0 && used >= seats {
+		return Lease{}, fmt.Errorf("%w: %d/%d in use", ErrSeatsExhausted, used, seats)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Lease{}, err
+	}
+	l := &Lease{ID: hex.EncodeToString(id), Sn: sn, Machine: machine, AcquiredAt: now, ExpiresAt: now.Add(t.ttl)}
+	t.byID[l.ID] = l
+	return *l, nil
+}
+
+// renew 续约，租约已过期或不存在时返回 ErrLeaseNotFound
+func (t *leaseTable) renew(id string, now time.Time) (Lease, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(now)
+	l, ok := t.byID[id]
+	if !ok {
+		return Lease{}, ErrLeaseNotFound
+	}
+	l.ExpiresAt = now.Add(t.ttl)
+	return *l, nil
+}
+
+// release 释放租约
+func (t *leaseTable) release(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.byID[id]; !ok {
+		return ErrLeaseNotFound
+	}
+	delete(t.byID, id)
+	return nil
+}
+
+// releaseSn 释放序列号的全部租约，用于吊销和删除
+func (t *leaseTable) releaseSn(sn string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, l := range t.byID {
+		if l.Sn == sn {
+			delete(t.byID, id)
+		}
+	}
+}
+
+// list 按序列号和申请时间排序返回有效租约
+func (t *leaseTable) list(now time.Time) []Lease {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expire(now)
+	list := make([]Lease, 0, len(t.byID))
+	for _, l := range t.byID {
+		list = append(list, *l)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Sn != list[j].Sn {
+			return list[i].Sn < list[j].Sn
+		}
+		return list[i].AcquiredAt.Before(list[j].AcquiredAt)
+	})
+	return list
+}
+
+// expire 清理过期租约；调用时需持有锁
+func (t *leaseTable) expire(now time.Time) {
+	for id, l := range t.byID {
+		if !now.Before(l.ExpiresAt) {
+			delete(t.byID, id)
+		}
+	}
+}
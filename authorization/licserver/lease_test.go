@@ -0,0 +1,51 @@
+package licserver
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLeaseTableSeatsAndExpiry(t *testing.T) {
+	table := newLeaseTable(time.Minute)
+	now := time.Now()
+
+	a, err := table.acquire("SN", "m1", 2, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.acquire("SN", "m2", 2, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.acquire("SN", "m3", 2, now); !errors.Is(err, ErrSeatsExhausted) {
+		t.Fatalf("err = %v, want exhausted", err)
+	}
+	// 同一机器重复申请不占用新席位
+	again, err := table.acquire("SN", "m1", 2, now.Add(time.Second))
+	if err != nil || again.ID != a.ID || !again.ExpiresAt.After(a.ExpiresAt) {
+		t.Fatalf("reacquire = %+v, %v", again, err)
+	}
+	if _, err := table.acquire("OTHER", "m3", 1, now); err != nil {
+		t.Fatal(err)
+	}
+
+	// m2 未续约过期后释放席位
+	if _, err := table.renew(a.ID, now.Add(50*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	later := now.Add(90 * time.Second)
+	if _, err := table.acquire("SN", "m3", 2, later); err != nil {
+		t.Fatalf("stale lease not expired: %v", err)
+	}
+	if len(table.list(later)) != 2 {
+		t.Fatalf("leases = %+v", table.list(later))
+	}
+	if _, err := table.renew("missing", later); !errors.Is(err, ErrLeaseNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+
+	table.releaseSn("SN")
+	if got := table.list(later); len(got) != 0 {
+		t.Fatalf("leases after releaseSn = %+v", got)
+	}
+}
@@ -0,0 +1,295 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-21 10:26:44
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-21 17:30:51
+ * @Description:自建授权服务，替代活码页面作为授权数据源
+ */
+package licserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/2Kil/tkstar/authorization"
+)
+
+// Options 服务配置
+type Options struct {
+	AdminToken string        // 管理接口令牌，为空时禁用管理接口
+	ReadToken  string        // 读取授权列表和申请席位的令牌，为空时无需认证
+	LeaseTTL   time.Duration // 席位租约有效期，默认 DefaultLeaseTTL，客户端需在此之前续约
+}
+
+// Server 授权服务，实现 http.Handler
+//
+//	GET    /v1/licenses                    未吊销的授权列表，供 authorization.ServerSource 读取
+//	GET    /v1/admin/licenses              全部记录（含吊销和备注）
+//	POST   /v1/admin/licenses              新增或覆盖，body 为 Record
+//	POST   /v1/admin/licenses/{sn}/extend  延长，body 为 {"days":30} 或 {"time":"2027-01-01"}
+//	POST   /v1/admin/licenses/{sn}/revoke  吊销
+//	DELETE /v1/admin/licenses/{sn}         删除
+//	GET    /v1/admin/leases                当前占用的席位
+//	POST   /v1/leases                      申请席位，body 为 {"sn":"A","machine":"机器码"}
+//	POST   /v1/leases/{id}/heartbeat       续约
+//	DELETE /v1/leases/{id}                 释放
+type Server struct {
+	store  *Store
+	opts   Options
+	mux    *http.ServeMux
+	leases *leaseTable
+	now    func() time.Time // 测试注入，为空使用 time.Now
+}
+
+// publicLicense 公开接口返回的字段
+type publicLicense struct {
+	Sn       string `json:"sn"`
+	Time     string `json:"time"`
+	Features string `json:"features,omitempty"`
+}
+
+// acquireRequest 申请席位的请求体
+type acquireRequest struct {
+	Sn      string `json:"sn"`
+	Machine string `json:"machine"`
+}
+
+// extendRequest 延长接口的请求体
+type extendRequest struct {
+	Days int    `json:"days"`
+	Time string `json:"time"`
+}
+
+// New 创建授权服务
+func New(store *Store, opts Options) *Server {
+	s := &Server{store: store, opts: opts, mux: http.NewServeMux(), leases: newLeaseTable(opts.LeaseTTL)}
+	s.mux.HandleFunc("GET /v1/licenses", s.read(s.handleList))
+	s.mux.HandleFunc("GET /v1/admin/licenses", s.admin(s.handleAdminList))
+	s.mux.HandleFunc("POST /v1/admin/licenses", s.admin(s.handlePut))
+	s.mux.HandleFunc("POST /v1/admin/licenses/{sn}/extend", s.admin(s.handleExtend))
+	s.mux.HandleFunc("POST /v1/admin/licenses/{sn}/revoke", s.admin(s.handleRevoke))
+	s.mux.HandleFunc("DELETE /v1/admin/licenses/{sn}", s.admin(s.handleDelete))
+	s.mux.HandleFunc("GET /v1/admin/leases", s.admin(s.handleLeaseList))
+	s.mux.HandleFunc("POST /v1/leases", s.read(s.handleAcquire))
+	s.mux.HandleFunc("POST /v1/leases/{id}/heartbeat", s.read(s.handleHeartbeat))
+	s.mux.HandleFunc("DELETE /v1/leases/{id}", s.read(s.handleRelease))
+	return s
+}
+
+// ServeHTTP 实现 http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	records := s.store.List(false)
+	list := make([]publicLicense, len(records))
+	for i, rec := range records {
+		list[i] = publicLicense{Sn: rec.Sn, Time: rec.Time, Features: rec.Features}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"licenses": list, "time": s.timeNow().Format(time.RFC3339)})
+}
+
+func (s *Server) handleAdminList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"licenses": s.store.List(true)})
+}
+
+func (s *Server) handlePut(w http.ResponseWriter, r *http.Request) {
+	var rec Record
+	if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rec, err := s.store.Put(rec)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	log.Printf("license %s set to %s", rec.Sn, rec.Time)
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (s *Server) handleExtend(w http.ResponseWriter, r *http.Request) {
+	var req extendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rec, err := s.store.Extend(r.PathValue("sn"), req.Time, time.Duration(req.Days)*24*time.Hour)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	log.Printf("license %s extended to %s", rec.Sn, rec.Time)
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	rec, err := s.store.Revoke(r.PathValue("sn"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.leases.releaseSn(rec.Sn)
+	log.Printf("license %s revoked", rec.Sn)
+	writeJSON(w, http.StatusOK, rec)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sn := r.PathValue("sn")
+	if err := s.store.Delete(sn); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	s.leases.releaseSn(authorization.NormalizeSn(sn))
+	log.Printf("license %s deleted", sn)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleLeaseList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"leases": s.leases.list(s.timeNow())})
+}
+
+func (s *Server) handleAcquire(w http.ResponseWriter, r *http.Request) {
+	var req acquireRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	req.Machine = strings.TrimSpace(req.Machine)
+	if req.Machine == "" {
+		writeError(w, http.StatusBadRequest, errors.New("empty machine"))
+		return
+	}
+	now := s.timeNow()
+	rec, status, err := s.usable(req.Sn, now)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	lease, err := s.leases.acquire(rec.Sn, req.Machine, rec.Seats, now)
+	if err != nil {
+		writeLeaseError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lease)
+}
+
+func (s *Server) handleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	now := s.timeNow()
+	lease, err := s.leases.renew(r.PathValue("id"), now)
+	if err != nil {
+		writeLeaseError(w, err)
+		return
+	}
+	// 持有租约期间授权到期，或存储被直接修改，都不再续约
+	if _, status, err := s.usable(lease.Sn, now); err != nil {
+		s.leases.release(lease.ID)
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, lease)
+}
+
+// usable 查询未吊销且未过期的授权，失败时返回对应的 HTTP 状态
+func (s *Server) usable(sn string, now time.Time) (Record, int, error) {
+	rec, ok := s.store.Get(sn)
+	if !ok || rec.Revoked {
+		return Record{}, http.StatusNotFound, ErrNotFound
+	}
+	if exp, err := authorization.ParseExpiry(rec.Time, time.Local); err != nil || !now.Before(exp) {
+		return Record{}, http.StatusForbidden, errors.New("license expired")
+	}
+	return rec, 0, nil
+}
+
+// timeNow 返回判断到期和租约使用的当前时间
+func (s *Server) timeNow() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *Server) handleRelease(w http.ResponseWriter, r *http.Request) {
+	if err := s.leases.release(r.PathValue("id")); err != nil {
+		writeLeaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// read 校验读取令牌
+func (s *Server) read(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.ReadToken != "" && !tokenMatch(r, s.opts.ReadToken) {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// admin 校验管理令牌，未配置令牌时拒绝所有管理请求
+func (s *Server) admin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.AdminToken == "" {
+			writeError(w, http.StatusForbidden, errors.New("admin api disabled"))
+			return
+		}
+		if !tokenMatch(r, s.opts.AdminToken) {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// tokenMatch 比较 Authorization: Bearer 令牌
+func tokenMatch(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Error writing response:", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeLeaseError 将租约错误映射为 HTTP 状态
+func writeLeaseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrSeatsExhausted):
+		writeError(w, http.StatusConflict, err)
+	case errors.Is(err, ErrLeaseNotFound):
+		writeError(w, http.StatusNotFound, err)
+	default:
+		log.Println("Error acquiring lease:", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+	}
+}
+
+// writeStoreError 将存储错误映射为 HTTP 状态
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, ErrInvalid):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		log.Println("Error saving store:", err)
+		writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+	}
+}
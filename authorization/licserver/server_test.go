@@ -0,0 +1,256 @@
+package licserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/2Kil/tkstar/authorization"
+)
+
+func newTestServer(t *testing.T, opts Options) *httptest.Server {
+	t.Helper()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "licenses.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(New(store, opts))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func adminDo(t *testing.T, srv *httptest.Server, method, path, token, body string) int {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServerWithServerSource(t *testing.T) {
+	srv := newTestServer(t, Options{AdminToken: "admin", ReadToken: "read"})
+
+	if code := adminDo(t, srv, "POST", "/v1/admin/licenses", "wrong", `{"sn":"A","time":"2099-01-01"}`); code != http.StatusUnauthorized {
+		t.Fatalf("wrong token status = %d", code)
+	}
+	if code := adminDo(t, srv, "POST", "/v1/admin/licenses", "admin", `{"sn":"A","time":"2000-01-01","features":"export,max_threads=4","note":"客户甲"}`); code != http.StatusOK {
+		t.Fatalf("add status = %d", code)
+	}
+	if code := adminDo(t, srv, "POST", "/v1/admin/licenses", "admin", `{"sn":"B","time":"2099-01-01"}`); code != http.StatusOK {
+		t.Fatalf("add status = %d", code)
+	}
+	if code := adminDo(t, srv, "POST", "/v1/admin/licenses", "admin", `{"sn":"C","time":"someday"}`); code != http.StatusBadRequest {
+		t.Fatalf("invalid time status = %d", code)
+	}
+
+	retry := authorization.RetryPolicy{Attempts: 1}
+	newClient := func(token string) *authorization.Client {
+		return authorization.NewSourceClient(&authorization.ServerSource{URL: srv.URL, Token: token, Retry: &retry})
+	}
+	client := newClient("read")
+	if client.CheckAccredit("A") || !client.CheckAccredit("B") {
+		t.Fatal("initial state mismatch")
+	}
+
+	if code := adminDo(t, srv, "POST", "/v1/admin/licenses/A/extend", "admin", `{"days":30}`); code != http.StatusOK {
+		t.Fatalf("extend status = %d", code)
+	}
+	if code := adminDo(t, srv, "POST", "/v1/admin/licenses/B/revoke", "admin", ``); code != http.StatusOK {
+		t.Fatalf("revoke status = %d", code)
+	}
+	if code := adminDo(t, srv, "POST", "/v1/admin/licenses/Z/revoke", "admin", ``); code != http.StatusNotFound {
+		t.Fatalf("revoke missing status = %d", code)
+	}
+
+	client = newClient("read")
+	r := client.CheckAccreditDetailed("A")
+	if !r.Valid || r.Remaining < 29*24*time.Hour || !strings.HasPrefix(r.Source, "server:") {
+		t.Fatalf("extended result = %+v", r)
+	}
+	if n, ok := client.Limit("A", "max_threads"); !ok || n != 4 {
+		t.Fatalf("Limit = %d, %v", n, ok)
+	}
+	if _, ok := r.Entry.Fields["note"]; ok {
+		t.Fatal("note exposed to clients")
+	}
+	if client.CheckAccredit("B") {
+		t.Fatal("revoked license still valid")
+	}
+
+	if _, err := newClient("").Refresh(); err == nil {
+		t.Fatal("missing read token accepted")
+	}
+}
+
+func TestServerAdminDisabledWithoutToken(t *testing.T) {
+	srv := newTestServer(t, Options{})
+	if code := adminDo(t, srv, "GET", "/v1/admin/licenses", "", ""); code != http.StatusForbidden {
+		t.Fatalf("status = %d", code)
+	}
+	if code := adminDo(t, srv, "GET", "/v1/licenses", "", ""); code != http.StatusOK {
+		t.Fatalf("public status = %d", code)
+	}
+}
+
+// fakeClock 可手动推进的时钟，替代 sleep 等待租约过期
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// waitFor 轮询直到 cond 成立，用于等待后台心跳
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newSeatServer(t *testing.T, records ...Record) (*Server, *httptest.Server, *fakeClock) {
+	t.Helper()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "licenses.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range records {
+		if _, err := store.Put(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock := &fakeClock{now: time.Now()}
+	s := New(store, Options{AdminToken: "admin", LeaseTTL: time.Minute})
+	s.now = clock.Now
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv, clock
+}
+
+func TestSeatsWithHeartbeat(t *testing.T) {
+	s, srv, clock := newSeatServer(t, Record{Sn: "A", Time: "2099-01-01", Seats: 1}, Record{Sn: "OLD", Time: "2000-01-01"})
+	src := &authorization.ServerSource{URL: srv.URL}
+	ctx := context.Background()
+
+	lost := make(chan error, 1)
+	seat, err := src.AcquireSeat(ctx, "a", seatOptions("m1", lost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.Acquire(ctx, "A", "m2"); !errors.Is(err, authorization.ErrSeatsExhausted) {
+		t.Fatalf("err = %v, want exhausted", err)
+	}
+	if _, err := src.Acquire(ctx, "OLD", "m2"); !errors.Is(err, authorization.ErrExpired) {
+		t.Fatalf("err = %v, want expired", err)
+	}
+	if _, err := src.Acquire(ctx, "Z", "m2"); !errors.Is(err, authorization.ErrNotFound) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+
+	// 心跳使租约在多个有效期后仍然存在：每次推进 50 秒，等待一次续约
+	for i := 0; i < 3; i++ {
+		clock.Advance(50 * time.Second)
+		waitFor(t, "heartbeat", func() bool {
+			now := clock.Now()
+			leases := s.leases.list(now)
+			return len(leases) == 1 && leases[0].ID == seat.Lease().ID && leases[0].ExpiresAt.Sub(now) > 55*time.Second
+		})
+	}
+	if err := seat.Release(); err != nil || seat.Release() != nil {
+		t.Fatalf("Release = %v", err)
+	}
+	if _, err := src.Acquire(ctx, "A", "m2"); err != nil {
+		t.Fatalf("seat not freed: %v", err)
+	}
+
+	// 不续约的租约过期后释放席位
+	clock.Advance(2 * time.Minute)
+	if leases := s.leases.list(clock.Now()); len(leases) != 0 {
+		t.Fatalf("leases = %+v", leases)
+	}
+
+	// 吊销后续约和重新申请都失败，席位丢失
+	seat, err = src.AcquireSeat(ctx, "A", seatOptions("m1", lost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := adminDo(t, srv, "POST", "/v1/admin/licenses/a/revoke", "admin", ""); code != http.StatusOK {
+		t.Fatalf("revoke status = %d", code)
+	}
+	select {
+	case err := <-lost:
+		if !errors.Is(err, authorization.ErrLeaseLost) || !errors.Is(err, authorization.ErrNotFound) {
+			t.Fatalf("lost err = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("seat not lost after revoke")
+	}
+	if !errors.Is(seat.Err(), authorization.ErrLeaseLost) {
+		t.Fatalf("Err = %v", seat.Err())
+	}
+	seat.Release()
+}
+
+func TestHeartbeatStopsAfterExpiry(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Format(TimeLayout)
+	s, srv, clock := newSeatServer(t, Record{Sn: "A", Time: expires})
+	src := &authorization.ServerSource{URL: srv.URL}
+
+	lost := make(chan error, 1)
+	seat, err := src.AcquireSeat(context.Background(), "A", seatOptions("m1", lost))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer seat.Release()
+
+	// 持有席位期间授权到期，续约失败并释放席位
+	clock.Advance(time.Hour)
+	select {
+	case err := <-lost:
+		if !errors.Is(err, authorization.ErrLeaseLost) || !errors.Is(err, authorization.ErrExpired) {
+			t.Fatalf("lost err = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("seat kept after license expired")
+	}
+	if leases := s.leases.list(clock.Now()); len(leases) != 0 {
+		t.Fatalf("leases = %+v", leases)
+	}
+}
+
+func seatOptions(machine string, lost chan error) authorization.SeatOptions {
+	return authorization.SeatOptions{
+		Machine:  machine,
+		Interval: 30 * time.Millisecond,
+		OnLost:   func(err error) { lost <- err },
+	}
+}
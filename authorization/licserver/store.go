@@ -0,0 +1,233 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-21 09:40:18
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-21 17:12:05
+ * @Description:自建授权服务的文件存储
+ */
+package licserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/2Kil/tkstar/authorization"
+)
+
+// TimeLayout 存储中到期时间的格式
+const TimeLayout = "2006-01-02 15:04:05"
+
+var (
+	ErrNotFound = errors.New("licserver: sn not found")
+	ErrInvalid  = errors.New("licserver: invalid license")
+)
+
+// Record 一条授权记录
+type Record struct {
+	Sn        string    `json:"sn"`
+	Time      string    `json:"time"`               // 到期时间，格式同授权表，支持 永久
+	Features  string    `json:"features,omitempty"` // 功能项，例如 export,max_threads=8
+	Seats     int       `json:"seats,omitempty"`    // 可同时使用的机器数，0 表示不限
+	Note      string    `json:"note,omitempty"`     // 备注，不对客户端公开
+	Revoked   bool      `json:"revoked,omitempty"`
+	RevokedAt time.Time `json:"revoked_at,omitzero"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// storeFile 存储文件内容
+type storeFile struct {
+	Licenses []Record `json:"licenses"`
+}
+
+// Store 以单个 JSON 文件保存授权记录，每次修改后整体写回
+type Store struct {
+	path    string
+	mu      sync.RWMutex
+	records map[string]*Record
+}
+
+// OpenStore 打开存储文件，文件不存在时创建空存储
+func OpenStore(path string) (*Store, error) {
+	s := &Store{path: path, records: map[string]*Record{}}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f storeFile
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	for i := range f.Licenses {
+		r := f.Licenses[i]
+		r.Sn = authorization.NormalizeSn(r.Sn)
+		if _, ok := s.records[r.Sn]; ok {
+			return nil, fmt.Errorf("failed to parse %s: duplicate sn %q", filepath.Base(path), r.Sn)
+		}
+		s.records[r.Sn] = &r
+	}
+	return s, nil
+}
+
+// List 按序列号排序返回记录，includeRevoked 为 false 时跳过已吊销的
+func (s *Store) List(includeRevoked bool) []Record {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		if r.Revoked && !includeRevoked {
+			continue
+		}
+		list = append(list, *r)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Sn < list[j].Sn })
+	return list
+}
+
+// Get 查询记录，序列号按 authorization.NormalizeSn 规范化后比较
+func (s *Store) Get(sn string) (Record, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.records[authorization.NormalizeSn(sn)]
+	if !ok {
+		return Record{}, false
+	}
+	return *r, true
+}
+
+// Put 新增或覆盖记录，覆盖时保留创建时间并取消吊销；序列号去掉空白并转为大写
+func (s *Store) Put(r Record) (Record, error) {
+	r.Sn = authorization.NormalizeSn(r.Sn)
+	r.Time = strings.TrimSpace(r.Time)
+	if r.Sn == "" {
+		return Record{}, fmt.Errorf("%w: empty sn", ErrInvalid)
+	}
+	if r.Seats < 0 {
+		return Record{}, fmt.Errorf("%w: negative seats", ErrInvalid)
+	}
+	if _, err := authorization.ParseExpiry(r.Time, time.Local); err != nil {
+		return Record{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	r.CreatedAt, r.UpdatedAt = now, now
+	r.Revoked, r.RevokedAt = false, time.Time{}
+	if old, ok := s.records[r.Sn]; ok {
+		r.CreatedAt = old.CreatedAt
+	}
+	return s.update(r)
+}
+
+// Extend 延长授权：until 不为空时直接设为该时间，否则在当前到期时间（已过期则为现在）上增加 d
+func (s *Store) Extend(sn, until string, d time.Duration) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.records[authorization.NormalizeSn(sn)]
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	r := *old
+	switch {
+	case strings.TrimSpace(until) != "":
+		if _, err := authorization.ParseExpiry(until, time.Local); err != nil {
+			return Record{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+		}
+		r.Time = strings.TrimSpace(until)
+	case d > 0:
+		now := time.Now()
+		base, err := authorization.ParseExpiry(r.Time, time.Local)
+		if err != nil || base.Before(now) {
+			base = now
+		}
+		if !base.Equal(authorization.Perpetual) {
+			r.Time = base.Add(d).Format(TimeLayout)
+		}
+	default:
+		return Record{}, fmt.Errorf("%w: nothing to extend", ErrInvalid)
+	}
+	r.UpdatedAt = time.Now()
+	return s.update(r)
+}
+
+// Revoke 吊销授权，记录保留以便查询和恢复
+func (s *Store) Revoke(sn string) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.records[authorization.NormalizeSn(sn)]
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	r := *old
+	if !r.Revoked {
+		r.Revoked, r.RevokedAt = true, time.Now()
+		r.UpdatedAt = r.RevokedAt
+	}
+	return s.update(r)
+}
+
+// Delete 删除记录
+func (s *Store) Delete(sn string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sn = authorization.NormalizeSn(sn)
+	old, ok := s.records[sn]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.records, sn)
+	if err := s.save(); err != nil {
+		s.records[sn] = old
+		return err
+	}
+	return nil
+}
+
+// update 写入一条记录并保存，保存失败时回滚；调用时需持有写锁
+func (s *Store) update(r Record) (Record, error) {
+	old, existed := s.records[r.Sn]
+	s.records[r.Sn] = &r
+	if err := s.save(); err != nil {
+		if existed {
+			s.records[r.Sn] = old
+		} else {
+			delete(s.records, r.Sn)
+		}
+		return Record{}, err
+	}
+	return r, nil
+}
+
+// save 先写临时文件再重命名；调用时需持有锁
+func (s *Store) save() error {
+	f := storeFile{Licenses: make([]Record, 0, len(s.records))}
+	for _, r := range s.records {
+		f.Licenses = append(f.Licenses, *r)
+	}
+	sort.Slice(f.Licenses, func(i, j int) bool { return f.Licenses[i].Sn < f.Licenses[j].Sn })
+	raw, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(s.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return err
+		}
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,101 @@
+package licserver
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStorePersistsAndExtends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "licenses.json")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Put(Record{Sn: " A ", Time: "2000-01-01", Features: "export"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Put(Record{Sn: "B", Time: "not a date"}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("err = %v, want invalid", err)
+	}
+
+	// 已过期的授权从现在开始延长
+	rec, err := store.Extend("A", "", 30*24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, err := time.ParseInLocation(TimeLayout, rec.Time, time.Local)
+	if err != nil || exp.Before(time.Now().Add(29*24*time.Hour)) {
+		t.Fatalf("extended time = %q", rec.Time)
+	}
+	if _, err := store.Extend("missing", "2099-01-01", 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v, want not found", err)
+	}
+	if _, err := store.Revoke("A"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := reopened.Get("A")
+	if !ok || !got.Revoked || got.Time != rec.Time || got.Features != "export" {
+		t.Fatalf("reopened = %+v", got)
+	}
+	if len(reopened.List(false)) != 0 || len(reopened.List(true)) != 1 {
+		t.Fatal("revoked record listed")
+	}
+
+	// 重新设置会取消吊销
+	if rec, err := reopened.Put(Record{Sn: "A", Time: "永久"}); err != nil || rec.Revoked || !rec.CreatedAt.Equal(got.CreatedAt) {
+		t.Fatalf("Put = %+v, %v", rec, err)
+	}
+	if err := reopened.Delete("A"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Delete("A"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestStoreNormalizesSn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "licenses.json")
+	store, err := OpenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, err := store.Put(Record{Sn: " ab-1 ", Time: "2099-01-01"}); err != nil || rec.Sn != "AB-1" {
+		t.Fatalf("Put = %+v, %v", rec, err)
+	}
+	for _, sn := range []string{"AB-1", "ab-1", " Ab - 1 "} {
+		if _, ok := store.Get(sn); !ok {
+			t.Errorf("Get(%q) not found", sn)
+		}
+	}
+	if _, err := store.Revoke("ab-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete("aB-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版本写入的小写序列号在打开时规范化，规范化后重复视为错误
+	if err := os.WriteFile(path, []byte(`{"licenses":[{"sn":"cd","time":"2099-01-01"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if store, err = OpenStore(path); err != nil {
+		t.Fatal(err)
+	}
+	if rec, ok := store.Get("CD"); !ok || rec.Sn != "CD" {
+		t.Fatalf("Get(CD) = %+v, %v", rec, ok)
+	}
+	if err := os.WriteFile(path, []byte(`{"licenses":[{"sn":"cd","time":"2099-01-01"},{"sn":"CD","time":"2099-01-01"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenStore(path); err == nil {
+		t.Fatal("expected duplicate sn to fail")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-25 10:03:27
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-25 15:41:52
+ * @Description:序列号匹配：精确、分组和通配符
+ */
+package authorization
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MatchKind 授权条目与序列号的匹配方式，数值越大优先级越高
+type MatchKind int
+
+const (
+	MatchNone     MatchKind = iota
+	MatchWildcard           // 通配符条目，例如 ABC*
+	MatchGroup              // 分组条目，例如 @siteA
+	MatchExact              // 序列号相同（忽略大小写和空白）
+)
+
+// String 返回匹配方式名称
+func (k MatchKind) String() string {
+	switch k {
+	case MatchWildcard:
+		return "wildcard"
+	case MatchGroup:
+		return "group"
+	case MatchExact:
+		return "exact"
+	default:
+		return "none"
+	}
+}
+
+// NormalizeSn 去掉所有空白并转为大写，用于比较序列号
+func NormalizeSn(s string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s))
+}
+
+// SetGroups 设置分组，键为组名，值为成员序列号
+// 授权表中 Sn 为组名（可带 @ 前缀）的条目对所有成员生效
+func (c *Client) SetGroups(groups map[string][]string) {
+	normalized := make(map[string]map[string]bool, len(groups))
+	for name, members := range groups {
+		set := make(map[string]bool, len(members))
+		for _, m := range members {
+			set[NormalizeSn(m)] = true
+		}
+		normalized[NormalizeSn(strings.TrimPrefix(strings.TrimSpace(name), "@"))] = set
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groups = normalized
+}
+
+// sortedMatch 命中的条目及其排序依据
+type sortedMatch struct {
+	item        Accredit
+	kind        MatchKind
+	specificity int // 通配符条目中非 * 字符的数量
+}
+
+// matchEntries 返回所有匹配 key 的条目，按优先级排序：
+// 精确 > 分组 > 通配符，通配符之间固定字符多的优先，同级按表格顺序
+func matchEntries(data []Accredit, key string, groups map[string]map[string]bool) []sortedMatch {
+	nkey := NormalizeSn(key)
+	if nkey == "" {
+		return nil
+	}
+	var matches []sortedMatch
+	for _, item := range data {
+		sn := NormalizeSn(item.Sn)
+		switch {
+		case sn == nkey:
+			matches = append(matches, sortedMatch{item: item, kind: MatchExact})
+		case groups[strings.TrimPrefix(sn, "@")][nkey]:
+			matches = append(matches, sortedMatch{item: item, kind: MatchGroup})
+		case strings.Contains(sn, "*") && wildcardMatch(sn, nkey):
+			matches = append(matches, sortedMatch{item: item, kind: MatchWildcard, specificity: len(sn) - strings.Count(sn, "*")})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].kind != matches[j].kind {
+			return matches[i].kind > matches[j].kind
+		}
+		return matches[i].specificity > matches[j].specificity
+	})
+	return matches
+}
+
+// wildcardMatch 判断 s 是否匹配 pattern，* 匹配任意长度字符
+func wildcardMatch(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(s, part)
+		if i < 0 {
+			return false
+		}
+		s = s[i+len(part):]
+	}
+	return len(parts) == 1 && s == "" || len(parts) > 1 && strings.HasSuffix(s, last)
+}
@@ -0,0 +1,63 @@
+package authorization
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWildcardMatch(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"ABC*", "ABC123", true},
+		{"ABC*", "ABC", true},
+		{"ABC*", "XABC1", false},
+		{"*", "ANY", true},
+		{"A*Z", "ABCZ", true},
+		{"A*Z", "AZZ", true},
+		{"A*Z", "AZB", false},
+		{"A*B*C", "AXBYC", true},
+		{"A*B*C", "ACB", false},
+		{"AB*BA", "ABA", false},
+	}
+	for _, c := range cases {
+		if got := wildcardMatch(c.pattern, c.s); got != c.want {
+			t.Errorf("wildcardMatch(%q, %q) = %v", c.pattern, c.s, got)
+		}
+	}
+}
+
+func TestCheckMatchPrecedence(t *testing.T) {
+	data := []Accredit{
+		{Sn: "*", Time: "2099-01-01"},
+		{Sn: "SITE-*", Time: "2099-02-02"},
+		{Sn: "SITE-A*", Time: "2099-03-03"},
+		{Sn: "@lab", Time: "2099-04-04"},
+		{Sn: "site-a1", Time: "2000-01-01"},
+	}
+	client := NewSourceClient(&stubSource{name: "stub", data: data})
+	client.SetGroups(map[string][]string{"LAB": {"site-a2", "x1"}})
+
+	// 精确条目优先，即使已过期
+	r := client.CheckAccreditDetailed(" SITE-A1 ")
+	if r.Valid || !errors.Is(r.Err, ErrExpired) || r.Match != MatchExact || len(r.Duplicates) != 3 {
+		t.Fatalf("exact result = %+v", r)
+	}
+	if r.Duplicates[0].Sn != "SITE-A*" || r.Duplicates[2].Sn != "*" {
+		t.Fatalf("duplicates order = %+v", r.Duplicates)
+	}
+
+	if r := client.CheckAccreditDetailed("Site-A2"); !r.Valid || r.Match != MatchGroup || r.Entry.Sn != "@lab" {
+		t.Fatalf("group result = %+v", r)
+	}
+	if r := client.CheckAccreditDetailed("site-a9"); !r.Valid || r.Match != MatchWildcard || r.Entry.Sn != "SITE-A*" {
+		t.Fatalf("specific wildcard result = %+v", r)
+	}
+	if r := client.CheckAccreditDetailed("other"); !r.Valid || r.Entry.Sn != "*" {
+		t.Fatalf("catch-all result = %+v", r)
+	}
+	if r := client.CheckAccreditDetailed(""); !errors.Is(r.Err, ErrNotFound) {
+		t.Fatalf("empty key result = %+v", r)
+	}
+}
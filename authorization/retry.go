@@ -0,0 +1,85 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-11 09:20:37
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-11 11:48:03
+ * @Description:请求重试策略
+ */
+package authorization
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy 网络请求的重试策略
+type RetryPolicy struct {
+	Attempts   int           // 最多尝试次数，小于 1 按 1 处理
+	Backoff    time.Duration // 首次重试前的等待时间，之后每次翻倍
+	MaxBackoff time.Duration // 单次等待上限，0 表示不限制
+}
+
+// DefaultRetry 默认重试策略：最多 2 次，间隔 3 秒
+var DefaultRetry = RetryPolicy{Attempts: 2, Backoff: 3 * time.Second}
+
+// delay 返回第 n 次重试（从 1 开始）前的等待时间
+func (p RetryPolicy) delay(n int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < n && d > 0; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// do 按策略执行 fn，直到成功、次数用尽或 ctx 结束
+// ctx 结束时返回 ctx.Err()，否则返回最后一次的错误
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	attempts := p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if e := sleepContext(ctx, p.delay(i)); e != nil {
+				return e
+			}
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+		if e := ctx.Err(); e != nil {
+			return e
+		}
+	}
+	return err
+}
+
+// retryOrDefault 数据源未配置策略时使用默认策略
+func retryOrDefault(p *RetryPolicy) RetryPolicy {
+	if p == nil {
+		return DefaultRetry
+	}
+	return *p
+}
+
+// sleepContext 等待 d 或 ctx 结束，以先到者为准
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelayDoublesUpToMax(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 3 * time.Second}
+	want := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for i, w := range want {
+		if got := p.delay(i + 1); got != w {
+			t.Fatalf("delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestRetryPolicyStopsOnContextCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	calls := 0
+	start := time.Now()
+	err := RetryPolicy{Attempts: 5, Backoff: time.Hour}.do(ctx, func() error {
+		calls++
+		return errors.New("fail")
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	if calls != 1 || time.Since(start) > time.Second {
+		t.Fatalf("calls = %d, elapsed = %v", calls, time.Since(start))
+	}
+}
+
+func TestCheckAccreditContextHonorsCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "A", Time: "2099-01-01"}}})
+	if client.CheckAccreditContext(ctx, "A") {
+		t.Fatal("expected cancelled context to fail the check")
+	}
+}
@@ -0,0 +1,330 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-27 09:55:32
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-27 17:14:06
+ * @Description:签名吊销列表
+ */
+package authorization
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// revocationPrefix 吊销列表格式版本前缀
+const revocationPrefix = "tkrl1."
+
+var (
+	ErrRevoked             = errors.New("authorization: license revoked")
+	ErrRevocationMalformed = errors.New("authorization: malformed revocation list")
+	ErrRevocationSignature = errors.New("authorization: invalid revocation list signature")
+	ErrRevocationRollback  = errors.New("authorization: revocation list older than current")
+)
+
+// RevocationList 吊销列表，版本号只增不减
+type RevocationList struct {
+	Version  uint64    `json:"ver"`
+	IssuedAt time.Time `json:"iat"`
+	Serials  []string  `json:"sn,omitempty"` // 吊销的序列号，比较时忽略大小写和空白
+	Machines []string  `json:"mc,omitempty"` // 吊销的机器码，用于离线签名令牌
+}
+
+// RevokesSn 判断序列号是否被吊销
+func (l *RevocationList) RevokesSn(sn string) bool {
+	if l == nil {
+		return false
+	}
+	n := NormalizeSn(sn)
+	for _, s := range l.Serials {
+		if NormalizeSn(s) == n {
+			return true
+		}
+	}
+	return false
+}
+
+// RevokesLicense 判断签名令牌是否被吊销（按机器码）
+func (l *RevocationList) RevokesLicense(lic *License) bool {
+	if l == nil || lic == nil {
+		return false
+	}
+	for _, m := range l.Machines {
+		if strings.EqualFold(strings.TrimSpace(m), strings.TrimSpace(lic.MachineCode)) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignRevocationList 使用签发令牌的私钥签名吊销列表
+// 格式 tkrl1.<base64url(载荷)>.<base64url(签名)>
+func SignRevocationList(priv ed25519.PrivateKey, list RevocationList) (string, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return "", fmt.Errorf("invalid private key")
+	}
+	if list.IssuedAt.IsZero() {
+		list.IssuedAt = time.Now()
+	}
+	payload, err := json.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	sig := ed25519.Sign(priv, []byte(revocationPrefix+body))
+	return revocationPrefix + body + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// ParseRevocationList 校验签名并解出吊销列表
+func ParseRevocationList(pub ed25519.PublicKey, blob string) (*RevocationList, error) {
+	blob = strings.TrimSpace(blob)
+	if !strings.HasPrefix(blob, revocationPrefix) {
+		return nil, ErrRevocationMalformed
+	}
+	body, sigText, ok := strings.Cut(strings.TrimPrefix(blob, revocationPrefix), ".")
+	if !ok {
+		return nil, ErrRevocationMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigText)
+	if err != nil {
+		return nil, ErrRevocationMalformed
+	}
+	if len(pub) != ed25519.PublicKeySize || !ed25519.Verify(pub, []byte(revocationPrefix+body), sig) {
+		return nil, ErrRevocationSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrRevocationMalformed
+	}
+	var list RevocationList
+	if err := json.Unmarshal(payload, &list); err != nil {
+		return nil, ErrRevocationMalformed
+	}
+	return &list, nil
+}
+
+// Revocations 吊销列表的拉取和本地缓存
+// 验证通过的列表以签名原文保存到 Path，离线时继续生效；版本号低于已见过的列表会被拒绝。
+// 设置 Cache 时在加密缓存中另存一份，Path 被删除或回退后从缓存恢复；
+// Path 损坏且缓存中没有可用副本时 Load 返回错误，校验按失败处理，直到导入新的列表
+type Revocations struct {
+	URL        string            // 吊销列表地址，为空时只能通过 Update 导入
+	PublicKey  ed25519.PublicKey // 签发公钥
+	Path       string            // 本地缓存文件，为空时只保存在内存
+	HTTPClient *http.Client
+	Cache      *Cache // 可选，另存一份的加密缓存；通过 Client.SetRevocations 使用时默认为客户端的缓存
+
+	mu     sync.Mutex
+	loaded bool
+	list   *RevocationList
+	blob   string
+	err    error
+}
+
+// Current 返回当前生效的吊销列表，从未见过或本地文件损坏时返回 nil
+func (r *Revocations) Current() *RevocationList {
+	list, _ := r.Load()
+	return list
+}
+
+// Load 返回当前生效的吊销列表，从未见过时返回 nil
+// 本地文件损坏且无法从 Cache 恢复时返回读取或解析错误
+func (r *Revocations) Load() (*RevocationList, error) {
+	return r.load(r.Cache)
+}
+
+// Refresh 从 URL 拉取吊销列表
+func (r *Revocations) Refresh(ctx context.Context) error {
+	return r.refresh(ctx, r.Cache)
+}
+
+// Update 校验并导入吊销列表，版本号低于当前列表时返回 ErrRevocationRollback
+func (r *Revocations) Update(blob string) error {
+	return r.update(blob, r.Cache)
+}
+
+func (r *Revocations) refresh(ctx context.Context, cache *Cache) error {
+	if r.URL == "" {
+		return nil
+	}
+	body, err := httpGet(ctx, httpClientOrDefault(r.HTTPClient), r.URL, nil)
+	if err != nil {
+		return err
+	}
+	return r.update(string(body), cache)
+}
+
+func (r *Revocations) update(blob string, cache *Cache) error {
+	blob = strings.TrimSpace(blob)
+	list, err := ParseRevocationList(r.PublicKey, blob)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loadLocked(cache)
+	if r.list != nil && list.Version < r.list.Version {
+		return fmt.Errorf("%w: got %d, have %d", ErrRevocationRollback, list.Version, r.list.Version)
+	}
+	if r.list != nil && list.Version == r.list.Version && r.err == nil {
+		return nil
+	}
+	if err := r.save(blob, cache); err != nil {
+		return err
+	}
+	r.list, r.blob, r.err = list, blob, nil
+	return nil
+}
+
+func (r *Revocations) load(cache *Cache) (*RevocationList, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loadLocked(cache)
+	return r.list, r.err
+}
+
+// loadLocked 首次使用时读取本地文件和缓存副本，取版本号较高的一份并补写另一处；调用时需持有锁
+func (r *Revocations) loadLocked(cache *Cache) {
+	if r.loaded {
+		return
+	}
+	r.loaded = true
+
+	var fileErr error
+	if r.Path != "" {
+		raw, err := os.ReadFile(r.Path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			fileErr = err
+		default:
+			blob := strings.TrimSpace(string(raw))
+			if list, err := ParseRevocationList(r.PublicKey, blob); err == nil {
+				r.list, r.blob = list, blob
+			} else {
+				fileErr = err
+			}
+		}
+	}
+	restored := false
+	if cache != nil {
+		if blob, ok := cache.anchor(r.anchorName()); ok {
+			if list, err := ParseRevocationList(r.PublicKey, blob); err != nil {
+				log.Println("Error parsing cached revocation list:", err)
+			} else if r.list == nil || list.Version > r.list.Version {
+				r.list, r.blob, restored = list, blob, true
+			}
+		}
+	}
+
+	if r.list == nil {
+		if fileErr != nil {
+			log.Println("Error loading revocation list:", fileErr)
+			r.err = fmt.Errorf("%s: %w", filepath.Base(r.Path), fileErr)
+		}
+		return
+	}
+	if restored || fileErr != nil {
+		log.Printf("revocation list %s restored to version %d", r.Path, r.list.Version)
+	}
+	if err := r.save(r.blob, cache); err != nil {
+		log.Println("Error saving revocation list:", err)
+	}
+}
+
+// save 写入本地文件和缓存副本，内容未变时跳过；调用时需持有锁
+func (r *Revocations) save(blob string, cache *Cache) error {
+	if r.Path != "" {
+		if raw, err := os.ReadFile(r.Path); err != nil || strings.TrimSpace(string(raw)) != blob {
+			if dir := filepath.Dir(r.Path); dir != "" {
+				if err := os.MkdirAll(dir, 0o700); err != nil {
+					return err
+				}
+			}
+			tmp := r.Path + ".tmp"
+			if err := os.WriteFile(tmp, []byte(blob+"\n"), 0o600); err != nil {
+				return err
+			}
+			if err := os.Rename(tmp, r.Path); err != nil {
+				return err
+			}
+		}
+	}
+	if cache != nil {
+		if cur, ok := cache.anchor(r.anchorName()); !ok || cur != blob {
+			return cache.setAnchor(r.anchorName(), blob)
+		}
+	}
+	return nil
+}
+
+// anchorName 吊销列表在授权缓存中的锚点名，按签发公钥区分
+func (r *Revocations) anchorName() string {
+	sum := sha256.Sum256(r.PublicKey)
+	return "revocations/" + hex.EncodeToString(sum[:8])
+}
+
+// SetRevocations 设置吊销列表，每次刷新授权数据时一并拉取，被吊销的序列号校验失败并返回 ErrRevoked
+func (c *Client) SetRevocations(r *Revocations) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revocations = r
+}
+
+// refreshRevocations 拉取吊销列表，失败时继续使用缓存
+func (c *Client) refreshRevocations(ctx context.Context) {
+	c.mu.Lock()
+	r := c.revocations
+	c.mu.Unlock()
+	if r == nil {
+		return
+	}
+	if err := r.refresh(ctx, c.revocationCache(r)); err != nil {
+		log.Println("Error refreshing revocation list:", err)
+	}
+}
+
+// revocationCache 吊销列表未单独设置 Cache 时使用客户端的缓存
+func (c *Client) revocationCache(r *Revocations) *Cache {
+	if r.Cache != nil {
+		return r.Cache
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache
+}
+
+// applyRevocations 授权有效但已被吊销时改为无效
+func (c *Client) applyRevocations(r *Result) {
+	c.mu.Lock()
+	rv := c.revocations
+	c.mu.Unlock()
+	if rv == nil || !r.Valid {
+		return
+	}
+	list, err := rv.load(c.revocationCache(rv))
+	if err != nil {
+		r.Valid = false
+		r.Remaining = 0
+		r.Err = err
+		return
+	}
+	if list.RevokesSn(r.Key) || list.RevokesSn(r.Entry.Sn) {
+		r.Valid = false
+		r.Remaining = 0
+		r.Err = fmt.Errorf("%w: list version %d", ErrRevoked, list.Version)
+	}
+}
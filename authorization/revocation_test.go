@@ -0,0 +1,151 @@
+package authorization
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRevocationListOverridesEntriesAndTokens(t *testing.T) {
+	pub, priv, err := GenerateTokenKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	served := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Write([]byte(served))
+	}))
+	defer srv.Close()
+	publish := func(list RevocationList) {
+		blob, err := SignRevocationList(priv, list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		served = blob
+		mu.Unlock()
+	}
+
+	path := filepath.Join(t.TempDir(), "revocations")
+	publish(RevocationList{Version: 2, Serials: []string{" dev-2 "}, Machines: []string{"MC-2"}})
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "DEV-1", Time: "2099-01-01"}, {Sn: "DEV-2", Time: "2099-01-01"}}})
+	client.SetRevocations(&Revocations{URL: srv.URL, PublicKey: pub, Path: path})
+
+	if !client.CheckAccredit("DEV-1") {
+		t.Fatal("DEV-1 should be valid")
+	}
+	if r := client.CheckAccreditDetailed("DEV-2"); r.Valid || !errors.Is(r.Err, ErrRevoked) {
+		t.Fatalf("revoked result = %+v", r)
+	}
+
+	// 离线时使用已保存的列表校验令牌
+	offline := &Revocations{PublicKey: pub, Path: path}
+	token, _ := IssueToken(priv, License{MachineCode: "MC-2", ExpiresAt: time.Now().Add(time.Hour)})
+	if _, err := (&TokenVerifier{PublicKey: pub, MachineCode: "MC-2", Revocations: offline}).Verify(token); !errors.Is(err, ErrRevoked) {
+		t.Fatalf("token err = %v, want revoked", err)
+	}
+	token, _ = IssueToken(priv, License{MachineCode: "MC-1", ExpiresAt: time.Now().Add(time.Hour)})
+	if _, err := (&TokenVerifier{PublicKey: pub, MachineCode: "MC-1", Revocations: offline}).Verify(token); err != nil {
+		t.Fatalf("token err = %v", err)
+	}
+
+	// 旧版本列表被拒绝，伪造签名被拒绝
+	old, _ := SignRevocationList(priv, RevocationList{Version: 1})
+	if err := offline.Update(old); !errors.Is(err, ErrRevocationRollback) {
+		t.Fatalf("rollback err = %v", err)
+	}
+	_, otherPriv, _ := GenerateTokenKey()
+	forged, _ := SignRevocationList(otherPriv, RevocationList{Version: 9})
+	if err := offline.Update(forged); !errors.Is(err, ErrRevocationSignature) {
+		t.Fatalf("forged err = %v", err)
+	}
+	if offline.Current().Version != 2 {
+		t.Fatalf("current = %+v", offline.Current())
+	}
+}
+
+func TestRevocationFloorKeptInCache(t *testing.T) {
+	pub, priv, err := GenerateTokenKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "revocations")
+	cache := &Cache{Path: filepath.Join(dir, "license.cache"), Key: "secret"}
+	v1, _ := SignRevocationList(priv, RevocationList{Version: 1})
+	v2, _ := SignRevocationList(priv, RevocationList{Version: 2, Machines: []string{"MC-2"}})
+	if err := (&Revocations{PublicKey: pub, Path: path, Cache: cache}).Update(v2); err != nil {
+		t.Fatal(err)
+	}
+
+	// 删除本地文件后从缓存恢复，旧版本仍被拒绝
+	os.Remove(path)
+	r := &Revocations{PublicKey: pub, Path: path, Cache: cache}
+	if list, err := r.Load(); err != nil || list.Version != 2 {
+		t.Fatalf("after delete = %+v, %v", list, err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("file not restored: %v", err)
+	}
+	if err := r.Update(v1); !errors.Is(err, ErrRevocationRollback) {
+		t.Fatalf("rollback err = %v", err)
+	}
+
+	// 用旧版本覆盖本地文件同样无效
+	os.WriteFile(path, []byte(v1), 0o600)
+	r = &Revocations{PublicKey: pub, Path: path, Cache: cache}
+	if list := r.Current(); list == nil || list.Version != 2 {
+		t.Fatalf("after replace = %+v", list)
+	}
+
+	// 通过客户端使用时默认写入客户端的缓存
+	os.Remove(path)
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "A", Time: "2099-01-01"}}})
+	client.SetCache(cache)
+	client.SetRevocations(&Revocations{PublicKey: pub, Path: path})
+	client.CheckAccredit("A")
+	if list := (&Revocations{PublicKey: pub, Path: path}).Current(); list == nil || list.Version != 2 {
+		t.Fatalf("client restore = %+v", list)
+	}
+}
+
+func TestRevocationCorruptFileFailsClosed(t *testing.T) {
+	pub, priv, err := GenerateTokenKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "revocations")
+	if err := os.WriteFile(path, []byte("tkrl1.garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r := &Revocations{PublicKey: pub, Path: path}
+	token, _ := IssueToken(priv, License{MachineCode: "MC-1", ExpiresAt: time.Now().Add(time.Hour)})
+	if _, err := (&TokenVerifier{PublicKey: pub, MachineCode: "MC-1", Revocations: r}).Verify(token); err == nil {
+		t.Fatal("corrupt list should fail verification")
+	}
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "A", Time: "2099-01-01"}}})
+	client.SetRevocations(r)
+	if res := client.CheckAccreditDetailed("A"); res.Valid || res.Err == nil {
+		t.Fatalf("corrupt list result = %+v", res)
+	}
+
+	// 导入新的列表后恢复
+	blob, _ := SignRevocationList(priv, RevocationList{Version: 1})
+	if err := r.Update(blob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&TokenVerifier{PublicKey: pub, MachineCode: "MC-1", Revocations: r}).Verify(token); err != nil {
+		t.Fatalf("after update err = %v", err)
+	}
+	if !client.CheckAccredit("A") {
+		t.Fatal("A should be valid after update")
+	}
+}
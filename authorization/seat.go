@@ -0,0 +1,276 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-22 14:20:09
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-22 18:02:36
+ * @Description:并发席位租约，配合 authorization/licserver 使用
+ */
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSeatsExhausted = errors.New("authorization: no free seats")
+	ErrLeaseLost      = errors.New("authorization: seat lease lost")
+)
+
+// Lease 服务端分配的席位租约
+type Lease struct {
+	ID         string    `json:"lease_id"`
+	Sn         string    `json:"sn"`
+	Machine    string    `json:"machine"`
+	AcquiredAt time.Time `json:"acquired_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Acquire 为 (sn, machine) 申请席位，同一机器重复申请返回原租约
+// 席位已满返回 ErrSeatsExhausted，序列号不存在或已吊销返回 ErrNotFound，已过期返回 ErrExpired
+func (s *ServerSource) Acquire(ctx context.Context, sn, machine string) (*Lease, error) {
+	var lease Lease
+	err := s.call(ctx, "POST", "/v1/leases", map[string]string{"sn": sn, "machine": machine}, &lease)
+	switch code := statusCode(err); code {
+	case 0:
+		return &lease, nil
+	case http.StatusConflict:
+		return nil, fmt.Errorf("%w: %w", ErrSeatsExhausted, err)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %w", ErrNotFound, err)
+	case http.StatusForbidden:
+		return nil, fmt.Errorf("%w: %w", ErrExpired, err)
+	}
+	return nil, err
+}
+
+// Heartbeat 续约并更新 lease.ExpiresAt，租约已被服务端回收时返回 ErrLeaseLost，授权已到期时同时包含 ErrExpired
+func (s *ServerSource) Heartbeat(ctx context.Context, lease *Lease) error {
+	var renewed Lease
+	err := s.call(ctx, "POST", "/v1/leases/"+lease.ID+"/heartbeat", nil, &renewed)
+	switch statusCode(err) {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrLeaseLost, err)
+	case http.StatusForbidden:
+		return fmt.Errorf("%w: %w: %w", ErrLeaseLost, ErrExpired, err)
+	}
+	if err != nil {
+		return err
+	}
+	lease.ExpiresAt = renewed.ExpiresAt
+	return nil
+}
+
+// Release 释放席位，租约已不存在时视为成功
+func (s *ServerSource) Release(ctx context.Context, lease *Lease) error {
+	err := s.call(ctx, "DELETE", "/v1/leases/"+lease.ID, nil, nil)
+	if statusCode(err) == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// SeatOptions 席位保持参数
+type SeatOptions struct {
+	Machine  string        // 机器码，通常为 hardware.SysSerialKey()
+	Interval time.Duration // 续约间隔，默认为租约有效期的 1/3
+	OnLost   func(error)   // 席位丢失时回调，之后不再续约
+}
+
+// Seat 已占用的席位，后台定期续约直到 Release
+type Seat struct {
+	src  *ServerSource
+	opts SeatOptions
+
+	mu    sync.Mutex
+	lease Lease
+	err   error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// AcquireSeat 申请席位并启动后台续约，程序退出前应调用 Release
+// 续约返回租约已回收时自动重新申请，重新申请失败或超过有效期仍未续约成功则视为丢失
+func (s *ServerSource) AcquireSeat(ctx context.Context, sn string, opts SeatOptions) (*Seat, error) {
+	if strings.TrimSpace(opts.Machine) == "" {
+		return nil, errors.New("authorization: empty machine code")
+	}
+	lease, err := s.Acquire(ctx, sn, opts.Machine)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = lease.ExpiresAt.Sub(lease.AcquiredAt) / 3
+		if opts.Interval <= 0 {
+			opts.Interval = 30 * time.Second
+		}
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	seat := &Seat{src: s, opts: opts, lease: *lease, cancel: cancel, done: make(chan struct{})}
+	go seat.run(runCtx)
+	return seat, nil
+}
+
+// Lease 返回当前租约
+func (s *Seat) Lease() Lease {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lease
+}
+
+// Err 席位丢失的原因，仍持有时为 nil
+func (s *Seat) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Release 停止续约并释放席位，可重复调用
+func (s *Seat) Release() error {
+	var err error
+	s.once.Do(func() {
+		s.cancel()
+		<-s.done
+		if s.Err() != nil {
+			return
+		}
+		lease := s.Lease()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err = s.src.Release(ctx, &lease)
+	})
+	return err
+}
+
+func (s *Seat) run(ctx context.Context) {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.renew(ctx); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+				log.Println("seat lost:", err)
+				if s.opts.OnLost != nil {
+					s.opts.OnLost(err)
+				}
+				return
+			}
+		}
+	}
+}
+
+// renew 续约一次，只在席位确定丢失时返回错误，网络错误在有效期内忽略
+func (s *Seat) renew(ctx context.Context) error {
+	lease := s.Lease()
+	err := s.src.Heartbeat(ctx, &lease)
+	if err == nil {
+		s.mu.Lock()
+		s.lease = lease
+		s.mu.Unlock()
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if !errors.Is(err, ErrLeaseLost) {
+		if time.Now().Before(lease.ExpiresAt) {
+			log.Println("seat heartbeat failed:", err)
+			return nil
+		}
+		return fmt.Errorf("%w: %w", ErrLeaseLost, err)
+	}
+
+	// 服务重启或网络中断导致租约被回收，重新申请
+	fresh, err := s.src.Acquire(ctx, lease.Sn, s.opts.Machine)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrLeaseLost, err)
+	}
+	s.mu.Lock()
+	s.lease = *fresh
+	s.mu.Unlock()
+	return nil
+}
+
+// statusError 授权服务返回的非 2xx 响应
+type statusError struct {
+	Code    int
+	Message string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("server returned %d: %s", e.Code, e.Message)
+}
+
+// statusCode 返回 statusError 的状态码，err 为 nil 时返回 0，其他错误返回 -1
+func statusCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.Code
+	}
+	return -1
+}
+
+// call 调用授权服务的 JSON 接口
+func (s *ServerSource) call(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		raw, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimRight(s.URL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	resp, err := httpClientOrDefault(s.HTTPClient).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(raw, &e) != nil || e.Error == "" {
+			e.Error = resp.Status
+		}
+		return &statusError{Code: resp.StatusCode, Message: e.Error}
+	}
+	if out == nil || len(raw) == 0 {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
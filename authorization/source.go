@@ -0,0 +1,315 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-08 10:12:30
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-08 16:40:12
+ * @Description:授权数据源
+ */
+package authorization
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Source 授权数据源，每次 Fetch 返回完整的授权列表
+type Source interface {
+	// Name 数据源名称，用于日志和结果追踪
+	Name() string
+	// Fetch 拉取授权列表
+	Fetch(ctx context.Context) ([]Accredit, error)
+}
+
+// ClewmSource 旧版活码数据源，解析 jump_url 指向的网页表格
+// Code 格式 active.clewm.net/q8tDtnl
+type ClewmSource struct {
+	Code       string
+	BaseURL    string        // 地址前缀，默认 DefaultClewmBaseURL
+	Table      TableSelector // 选择页面中的表格，默认第一个
+	HTTPClient *http.Client
+	Retry      *RetryPolicy // 为空使用 DefaultRetry
+}
+
+// Name 实现 Source
+func (s *ClewmSource) Name() string { return "clewm" }
+
+// Fetch 实现 Source
+func (s *ClewmSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	return fetchClewm(ctx, s)
+}
+
+// CaoliaoSource 新版活码数据源，通过 batch-requests 接口获取
+// Code 格式 qr61.cn/o78kxB/q8tDtnl
+type CaoliaoSource struct {
+	Code       string
+	Pwd        string
+	Endpoint   string        // batch-requests 接口地址，默认 DefaultCaoliaoEndpoint
+	Table      TableSelector // 选择返回内容中的表格，默认第一个
+	HTTPClient *http.Client
+	Retry      *RetryPolicy // 为空使用 DefaultRetry
+}
+
+// Name 实现 Source
+func (s *CaoliaoSource) Name() string { return "caoliao" }
+
+// Fetch 实现 Source
+func (s *CaoliaoSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	return fetchCaoliao(ctx, s)
+}
+
+// FileSource 本地文件数据源，按扩展名识别格式
+// .json 同 JSONSource，.csv 同 CSVSource，其余按 HTML 表格解析
+type FileSource struct {
+	Path  string
+	Table TableSelector // HTML 文件中选择的表格
+	// JSON 和 CSV 的解析参数，零值使用默认配置
+	JSON JSONFormat
+	CSV  CSVFormat
+}
+
+// Name 实现 Source
+func (s *FileSource) Name() string { return "file:" + s.Path }
+
+// Fetch 实现 Source
+func (s *FileSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	body, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []Accredit
+	switch strings.ToLower(filepath.Ext(s.Path)) {
+	case ".json":
+		data, err = s.JSON.parse(body, expiryParserFrom(ctx))
+	case ".csv":
+		data, err = s.CSV.parse(body, expiryParserFrom(ctx))
+	default:
+		data, err = htmlToAccredits(string(body), s.Table, expiryParserFrom(ctx))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no data found")
+	}
+	return data, nil
+}
+
+// JSONFormat 描述 JSON 授权列表的字段布局
+type JSONFormat struct {
+	ListKey   string // 列表所在的顶层字段，为空表示顶层即数组
+	SnField   string // 序列号字段名，默认 sn
+	TimeField string // 时间字段名，默认 time
+}
+
+func (f JSONFormat) parse(body []byte, parse expiryFunc) ([]Accredit, error) {
+	parse = parse.orDefault()
+	snField, timeField := f.SnField, f.TimeField
+	if snField == "" {
+		snField = "sn"
+	}
+	if timeField == "" {
+		timeField = "time"
+	}
+
+	list := json.RawMessage(body)
+	if f.ListKey != "" {
+		var wrapper map[string]json.RawMessage
+		if err := json.Unmarshal(body, &wrapper); err != nil {
+			return nil, fmt.Errorf("failed to parse json: %w", err)
+		}
+		var ok bool
+		if list, ok = wrapper[f.ListKey]; !ok {
+			return nil, fmt.Errorf("json key %q not found", f.ListKey)
+		}
+	}
+
+	var rows []map[string]any
+	if err := json.Unmarshal(list, &rows); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %w", err)
+	}
+
+	data := make([]Accredit, 0, len(rows))
+	for i, row := range rows {
+		sn := jsonString(row[snField])
+		if sn == "" {
+			continue
+		}
+		item := Accredit{Sn: sn, Time: jsonString(row[timeField]), Row: i + 1, Fields: make(map[string]string, len(row))}
+		for k, v := range row {
+			item.Fields[k] = jsonString(v)
+		}
+		item.Expires, _ = parse(item.Time)
+		data = append(data, item)
+	}
+	return data, nil
+}
+
+// jsonString 将 JSON 字段值转换为字符串，数字保持原样输出
+func jsonString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return strings.TrimSpace(fmt.Sprint(val))
+	}
+}
+
+// CSVFormat 描述 CSV 授权列表的列布局
+type CSVFormat struct {
+	SnColumn   int  // 序列号列下标，默认 0
+	TimeColumn int  // 时间列下标，零值且 SnColumn 也为零时取 1
+	SkipHeader bool // 首行是否为表头，表头用作 Fields 的键
+	Comma      rune // 分隔符，默认逗号
+}
+
+func (f CSVFormat) parse(body []byte, parse expiryFunc) ([]Accredit, error) {
+	snCol, timeCol := f.SnColumn, f.TimeColumn
+	if snCol == 0 && timeCol == 0 {
+		timeCol = 1
+	}
+
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	if f.Comma != 0 {
+		r.Comma = f.Comma
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv: %w", err)
+	}
+	var header []string
+	if f.SkipHeader && len(records) > 0 {
+		header, records = records[0], records[1:]
+	}
+	return rowsToAccredits(records, header, snCol, timeCol, parse), nil
+}
+
+// JSONSource 通用 HTTP JSON 数据源
+type JSONSource struct {
+	URL        string
+	Header     http.Header
+	Format     JSONFormat
+	HTTPClient *http.Client
+}
+
+// Name 实现 Source
+func (s *JSONSource) Name() string { return "json:" + s.URL }
+
+// Fetch 实现 Source
+func (s *JSONSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	body, err := httpGet(ctx, httpClientOrDefault(s.HTTPClient), s.URL, s.Header)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.Format.parse(body, expiryParserFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no data found")
+	}
+	return data, nil
+}
+
+// CSVSource 通用 HTTP CSV 数据源
+type CSVSource struct {
+	URL        string
+	Header     http.Header
+	Format     CSVFormat
+	HTTPClient *http.Client
+}
+
+// Name 实现 Source
+func (s *CSVSource) Name() string { return "csv:" + s.URL }
+
+// Fetch 实现 Source
+func (s *CSVSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	body, err := httpGet(ctx, httpClientOrDefault(s.HTTPClient), s.URL, s.Header)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.Format.parse(body, expiryParserFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no data found")
+	}
+	return data, nil
+}
+
+// ServerSource 自建授权服务数据源（见 authorization/licserver），读取 URL/v1/licenses
+type ServerSource struct {
+	URL        string // 服务地址，例如 http://192.168.1.10:8520
+	Token      string // 读取令牌，服务端未设置时留空
+	HTTPClient *http.Client
+	Retry      *RetryPolicy // 为空使用 DefaultRetry
+}
+
+// Name 实现 Source
+func (s *ServerSource) Name() string { return "server:" + s.URL }
+
+// Fetch 实现 Source
+func (s *ServerSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	header := http.Header{}
+	if s.Token != "" {
+		header.Set("Authorization", "Bearer "+s.Token)
+	}
+	var body []byte
+	err := retryOrDefault(s.Retry).do(ctx, func() error {
+		var err error
+		body, err = httpGet(ctx, httpClientOrDefault(s.HTTPClient), strings.TrimRight(s.URL, "/")+"/v1/licenses", header)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 服务端只返回未吊销的序列号
+	return JSONFormat{ListKey: "licenses"}.parse(body, expiryParserFrom(ctx))
+}
+
+// httpGet 发送 GET 请求并返回响应体，非 2xx 状态视为错误
+func httpGet(ctx context.Context, client *http.Client, rawURL string, header http.Header) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	ObserveServerDate(ctx, resp)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func httpClientOrDefault(client *http.Client) *http.Client {
+	if client == nil {
+		return defaultHTTPClient
+	}
+	return client
+}
@@ -0,0 +1,76 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type stubSource struct {
+	name string
+	data []Accredit
+	err  error
+}
+
+func (s *stubSource) Name() string { return s.name }
+
+func (s *stubSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	return cloneAccredits(s.data), s.err
+}
+
+func TestFileSourceParsesJSONAndCSV(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "list.json")
+	csvPath := filepath.Join(dir, "list.csv")
+	if err := os.WriteFile(jsonPath, []byte(`{"items":[{"id":"A","exp":"2099-01-01"},{"id":1234,"exp":"2099-02-02"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(csvPath, []byte("sn,time\nB, 2099-03-03\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := (&FileSource{Path: jsonPath, JSON: JSONFormat{ListKey: "items", SnField: "id", TimeField: "exp"}}).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("json fetch: %v", err)
+	}
+	if len(got) != 2 || got[0].Sn != "A" || got[1].Sn != "1234" || got[1].Time != "2099-02-02" {
+		t.Fatalf("json data = %+v", got)
+	}
+
+	got, err = (&FileSource{Path: csvPath, CSV: CSVFormat{SkipHeader: true}}).Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("csv fetch: %v", err)
+	}
+	if len(got) != 1 || got[0].Sn != "B" || got[0].Time != "2099-03-03" {
+		t.Fatalf("csv data = %+v", got)
+	}
+}
+
+func TestRefreshFallsBackThroughSources(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("C,2099-01-01\n"))
+	}))
+	defer srv.Close()
+
+	client := NewSourceClient(
+		&stubSource{name: "down", err: errors.New("offline")},
+		&CSVSource{URL: srv.URL},
+	)
+	if !client.CheckAccredit("C") {
+		t.Fatal("expected key from second source to be valid")
+	}
+}
+
+func TestRefreshReportsAllSourceErrors(t *testing.T) {
+	client := NewSourceClient(
+		&stubSource{name: "a", err: errors.New("first")},
+		&stubSource{name: "b"},
+	)
+	if _, err := client.Refresh(); err == nil {
+		t.Fatal("expected error when every source fails")
+	}
+}
@@ -0,0 +1,129 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-14 10:02:44
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-14 15:31:09
+ * @Description:授权表格模型
+ */
+package authorization
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// defaultHeaderNames 表格没有 <th> 时，首行序列号列或时间列为这些文本（不区分大小写）即视为表头
+var defaultHeaderNames = []string{
+	"sn", "serial", "key", "license", "序列号", "卡密", "授权码", "激活码", "机器码", "编号",
+	"time", "date", "expires", "expiry", "expire", "到期时间", "到期", "过期时间", "有效期", "时间",
+}
+
+// rowsToAccredits 将表格行按原顺序转换为授权列表
+// header 为表头，用作 Fields 的键；为空或列数不足时使用 col1、col2…，重名的列依次加后缀 _2、_3…
+// parse 解析到期时间，为空使用 ParseExpiry 和本地时区
+func rowsToAccredits(rows [][]string, header []string, snCol, timeCol int, parse expiryFunc) []Accredit {
+	parse = parse.orDefault()
+	width := len(header)
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	names := columnNames(header, width)
+	data := make([]Accredit, 0, len(rows))
+	for i, row := range rows {
+		if snCol >= len(row) || timeCol >= len(row) {
+			continue
+		}
+		sn := strings.TrimSpace(row[snCol])
+		if sn == "" {
+			continue
+		}
+		item := Accredit{
+			Sn:     sn,
+			Time:   strings.TrimSpace(row[timeCol]),
+			Row:    i + 1,
+			Fields: make(map[string]string, len(row)),
+		}
+		for j, cell := range row {
+			item.Fields[names[j]] = strings.TrimSpace(cell)
+		}
+		var err error
+		if item.Expires, err = parse(item.Time); err != nil {
+			log.Printf("row %d: sn %s has invalid expiry %q: %v", item.Row, sn, item.Time, err)
+		}
+		data = append(data, item)
+	}
+	return data
+}
+
+// splitHeader 识别表头：hasHeader 为真（<th> 或 TableSelector.HasHeader），或首行序列号列、时间列是常见表头名时，首行视为表头
+// 不按时间能否解析判断，时间写错的数据行仍保留并由 rowsToAccredits 记录日志
+func splitHeader(rows [][]string, hasHeader bool, snCol, timeCol int) ([]string, [][]string) {
+	if len(rows) == 0 {
+		return nil, rows
+	}
+	first := rows[0]
+	if hasHeader {
+		return first, rows[1:]
+	}
+	for _, col := range []int{snCol, timeCol} {
+		if col < len(first) && isHeaderName(first[col]) {
+			return first, rows[1:]
+		}
+	}
+	return nil, rows
+}
+
+func isHeaderName(cell string) bool {
+	cell = strings.TrimSpace(cell)
+	for _, name := range defaultHeaderNames {
+		if strings.EqualFold(cell, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnNames 返回前 n 列的字段名，重名时第二次出现起加 _2、_3…，避免 colspan 表头互相覆盖
+func columnNames(header []string, n int) []string {
+	names := make([]string, n)
+	seen := make(map[string]bool, n)
+	for i := range names {
+		name := fmt.Sprintf("col%d", i+1)
+		if i < len(header) {
+			if h := strings.TrimSpace(header[i]); h != "" {
+				name = h
+			}
+		}
+		unique := name
+		for k := 2; seen[unique]; k++ {
+			unique = fmt.Sprintf("%s_%d", name, k)
+		}
+		seen[unique] = true
+		names[i] = unique
+	}
+	return names
+}
+
+// Duplicates 找出授权列表中重复出现的序列号，按首次出现顺序返回每组条目
+func Duplicates(data []Accredit) [][]Accredit {
+	index := make(map[string]int)
+	var groups [][]Accredit
+	for _, item := range data {
+		i, ok := index[item.Sn]
+		if !ok {
+			index[item.Sn] = len(groups)
+			groups = append(groups, []Accredit{item})
+			continue
+		}
+		groups[i] = append(groups[i], item)
+	}
+
+	dups := groups[:0]
+	for _, g := range groups {
+		if len(g) > 1 {
+			dups = append(dups, g)
+		}
+	}
+	return dups
+}
@@ -0,0 +1,106 @@
+package authorization
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHTMLToAccreditsKeepsColumnsAndOrder(t *testing.T) {
+	html := `<table>
+<tr><th>SN</th><th>到期</th><th>客户</th><th>座席</th></tr>
+<tr><td>B</td><td>2099-01-01</td><td>acme</td><td>3</td></tr>
+<tr><td>A</td><td><span>2099-02-02</span></td><td>initech</td><td>1</td></tr>
+<tr><td>B</td><td>2000-01-01</td><td>acme</td><td>1</td></tr>
+</table>`
+
+	data, err := htmlToAccredits(html, TableSelector{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3 {
+		t.Fatalf("rows = %d, want 3", len(data))
+	}
+	if data[0].Sn != "B" || data[1].Sn != "A" || data[2].Row != 3 {
+		t.Fatalf("order not preserved: %+v", data)
+	}
+	if data[1].Fields["客户"] != "initech" || data[1].Fields["座席"] != "1" || data[1].Expires.Year() != 2099 {
+		t.Fatalf("fields = %+v", data[1])
+	}
+
+	dups := Duplicates(data)
+	if len(dups) != 1 || len(dups[0]) != 2 || dups[0][0].Sn != "B" {
+		t.Fatalf("duplicates = %+v", dups)
+	}
+}
+
+func TestHTMLToAccreditsWithoutHeader(t *testing.T) {
+	data, _ := htmlToAccredits(`<table><tr><td>A</td><td>2099-01-01</td><td>x</td></tr></table>`, TableSelector{}, nil)
+	if len(data) != 1 || data[0].Fields["col3"] != "x" {
+		t.Fatalf("data = %+v", data)
+	}
+}
+
+func TestHeaderDetectionUsesClientParser(t *testing.T) {
+	// 没有 <th>，日期为日/月/年，默认解析器识别不了，首行也不能因此被当作表头
+	path := filepath.Join(t.TempDir(), "licenses.html")
+	page := `<table><tr><td>A</td><td>07/01/2099</td></tr><tr><td>B</td><td>08/01/2099</td></tr></table>`
+	if err := os.WriteFile(path, []byte(page), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client := NewSourceClient(&FileSource{Path: path})
+	client.SetExpiryParser(func(val string, loc *time.Location) (time.Time, error) {
+		return time.ParseInLocation("02/01/2006", val, loc)
+	})
+	data, err := client.Refresh()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data[0].Sn != "A" || data[0].Expires.Month() != time.January {
+		t.Fatalf("data = %+v", data)
+	}
+	if !client.CheckAccredit("A") {
+		t.Fatal("first row dropped as header")
+	}
+}
+
+func TestCheckAccreditDetailedReportsDuplicates(t *testing.T) {
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{
+		{Sn: "A", Time: "2099-01-01", Row: 1},
+		{Sn: "A", Time: "2000-01-01", Row: 2},
+	}})
+	r := client.CheckAccreditDetailed("A")
+	if !r.Valid || r.Entry.Row != 1 || len(r.Duplicates) != 1 || r.Duplicates[0].Row != 2 {
+		t.Fatalf("result = %+v", r)
+	}
+}
+
+func TestSplitHeaderByColumnNames(t *testing.T) {
+	// 没有 <th> 的首行时间写错时仍是数据行，不能被当作表头丢弃
+	page := `<table><tr><td>A</td><td>2099-13-45</td></tr><tr><td>B</td><td>2099-01-01</td></tr></table>`
+	data, err := htmlToAccredits(page, TableSelector{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data[0].Sn != "A" || !data[0].Expires.IsZero() || data[0].Fields["col1"] != "A" {
+		t.Fatalf("data = %+v", data)
+	}
+
+	// 首行是常见表头名时识别为表头
+	page = `<table><tr><td>序列号</td><td>到期时间</td><td>客户</td></tr><tr><td>A</td><td>2099-01-01</td><td>acme</td></tr></table>`
+	data, _ = htmlToAccredits(page, TableSelector{}, nil)
+	if len(data) != 1 || data[0].Fields["客户"] != "acme" {
+		t.Fatalf("named header data = %+v", data)
+	}
+
+	// 自定义表头名需通过 TableSelector.HasHeader 指定
+	page = `<table><tr><td>设备</td><td>截止</td></tr><tr><td>A</td><td>2099-01-01</td></tr></table>`
+	if data, _ = htmlToAccredits(page, TableSelector{}, nil); len(data) != 2 {
+		t.Fatalf("unnamed header data = %+v", data)
+	}
+	data, _ = htmlToAccredits(page, TableSelector{HasHeader: true}, nil)
+	if len(data) != 1 || data[0].Fields["截止"] != "2099-01-01" {
+		t.Fatalf("selector header data = %+v", data)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-10 10:05:11
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-10 17:26:48
+ * @Description:离线签名授权
+ */
+package authorization
+
+import (
+	"crypto/ed25519"
+	crand "crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// tokenPrefix 令牌格式版本前缀
+const tokenPrefix = "tk1."
+
+var (
+	ErrTokenMalformed = errors.New("authorization: malformed token")
+	ErrTokenSignature = errors.New("authorization: invalid token signature")
+	ErrTokenExpired   = errors.New("authorization: token expired")
+	ErrTokenMachine   = errors.New("authorization: token bound to another machine")
+	ErrTokenProduct   = errors.New("authorization: token issued for another product")
+	ErrTokenNoMachine = errors.New("authorization: verifier has no machine code")
+)
+
+// License 签名令牌中的授权载荷
+type License struct {
+	MachineCode string    `json:"mc"`                 // 机器码，通常为 hardware.SysSerialKey()
+	Customer    string    `json:"cid,omitempty"`      // 客户标识
+	Product     string    `json:"prd,omitempty"`      // 产品标识，激活流程中来自激活请求
+	Features    []string  `json:"features,omitempty"` // 功能列表
+	IssuedAt    time.Time `json:"iat"`                // 签发时间
+	ExpiresAt   time.Time `json:"exp"`                // 过期时间，零值表示永久
+}
+
+// Expired 判断在 now 时刻是否已过期
+func (l *License) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && now.After(l.ExpiresAt)
+}
+
+// GenerateTokenKey 生成签发令牌用的 Ed25519 密钥对
+func GenerateTokenKey() (ed25519.PublicKey, ed25519.PrivateKey, error) {
+	return ed25519.GenerateKey(crand.Reader)
+}
+
+// EncodeKey 将公钥或私钥编码为便于嵌入代码的 Base64 字符串
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// ParsePublicKey 解析 EncodeKey 输出的公钥
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key")
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// ParsePrivateKey 解析 EncodeKey 输出的私钥
+func ParsePrivateKey(s string) (ed25519.PrivateKey, error) {
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(b) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	return ed25519.PrivateKey(b), nil
+}
+
+// IssueToken 使用私钥签发授权令牌
+// 令牌格式 tk1.<base64url(载荷)>.<base64url(签名)>
+func IssueToken(priv ed25519.PrivateKey, lic License) (string, error) {
+	if len(priv) != ed25519.PrivateKeySize {
+		return "", fmt.Errorf("invalid private key")
+	}
+	if lic.MachineCode == "" {
+		return "", fmt.Errorf("machine code is required")
+	}
+	if lic.IssuedAt.IsZero() {
+		lic.IssuedAt = time.Now()
+	}
+	payload, err := json.Marshal(lic)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	sig := ed25519.Sign(priv, []byte(tokenPrefix+body))
+	return tokenPrefix + body + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// TokenVerifier 离线校验签名令牌
+type TokenVerifier struct {
+	PublicKey   ed25519.PublicKey // 嵌入程序的签发公钥
+	MachineCode string            // 本机机器码，必填，为空时返回 ErrTokenNoMachine
+	AnyMachine  bool              // 为真时不校验机器码，只用于有意签发的不绑定机器的令牌
+	Product     string            // 产品标识，为空时不校验
+	Revocations *Revocations      // 可选，见过的吊销列表中包含本机机器码时返回 ErrRevoked，本地列表损坏时返回其错误
+	Clock       *Clock            // 可选，防回拨时钟，为空时使用系统时间
+}
+
+// Verify 校验令牌字符串，返回其中的授权载荷
+// 设置 Clock 时按其时间判断过期，时钟被回拨时返回 ErrClockTampered
+func (v *TokenVerifier) Verify(token string) (*License, error) {
+	if v.Clock == nil {
+		return v.verifyAt(token, time.Now())
+	}
+	now, cerr := v.Clock.Now()
+	lic, err := v.verifyAt(token, now)
+	if err == nil && cerr != nil {
+		return lic, cerr
+	}
+	return lic, err
+}
+
+// VerifyFile 从文件读取令牌并校验
+func (v *TokenVerifier) VerifyFile(path string) (*License, error) {
+	token, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return v.Verify(string(token))
+}
+
+func (v *TokenVerifier) verifyAt(token string, now time.Time) (*License, error) {
+	lic, err := parseToken(v.PublicKey, token)
+	if err != nil {
+		return nil, err
+	}
+	if !v.AnyMachine && strings.TrimSpace(v.MachineCode) == "" {
+		return lic, ErrTokenNoMachine
+	}
+	if !v.AnyMachine && !strings.EqualFold(strings.TrimSpace(lic.MachineCode), strings.TrimSpace(v.MachineCode)) {
+		return lic, ErrTokenMachine
+	}
+	if v.Product != "" && lic.Product != v.Product {
+		return lic, ErrTokenProduct
+	}
+	if v.Revocations != nil {
+		list, err := v.Revocations.Load()
+		if err != nil {
+			return lic, err
+		}
+		if list.RevokesLicense(lic) {
+			return lic, ErrRevoked
+		}
+	}
+	if lic.Expired(now) {
+		return lic, ErrTokenExpired
+	}
+	return lic, nil
+}
+
+// parseToken 校验签名并解出载荷，不检查过期和机器码
+func parseToken(pub ed25519.PublicKey, token string) (*License, error) {
+	token = strings.TrimSpace(token)
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, ErrTokenMalformed
+	}
+	body, sigText, ok := strings.Cut(strings.TrimPrefix(token, tokenPrefix), ".")
+	if !ok {
+		return nil, ErrTokenMalformed
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigText)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if len(pub) != ed25519.PublicKeySize || !ed25519.Verify(pub, []byte(tokenPrefix+body), sig) {
+		return nil, ErrTokenSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var lic License
+	if err := json.Unmarshal(payload, &lic); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	return &lic, nil
+}
@@ -0,0 +1,68 @@
+package authorization
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenIssueAndVerify(t *testing.T) {
+	pub, priv, err := GenerateTokenKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err = ParsePublicKey(EncodeKey(pub))
+	if err != nil {
+		t.Fatalf("parse public key: %v", err)
+	}
+
+	token, err := IssueToken(priv, License{
+		MachineCode: "ABC123",
+		Customer:    "acme",
+		Features:    []string{"export"},
+		ExpiresAt:   time.Now().Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "license.key")
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	lic, err := (&TokenVerifier{PublicKey: pub, MachineCode: "abc123"}).VerifyFile(path)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if lic.Customer != "acme" || len(lic.Features) != 1 {
+		t.Fatalf("license = %+v", lic)
+	}
+
+	if _, err := (&TokenVerifier{PublicKey: pub, MachineCode: "OTHER"}).Verify(token); !errors.Is(err, ErrTokenMachine) {
+		t.Fatalf("other machine err = %v", err)
+	}
+	if _, err := (&TokenVerifier{PublicKey: pub}).Verify(token); !errors.Is(err, ErrTokenNoMachine) {
+		t.Fatalf("no machine err = %v", err)
+	}
+	if _, err := (&TokenVerifier{PublicKey: pub, AnyMachine: true}).Verify(token); err != nil {
+		t.Fatalf("any machine err = %v", err)
+	}
+	if _, err := (&TokenVerifier{PublicKey: pub, MachineCode: "ABC123"}).verifyAt(token, time.Now().Add(48*time.Hour)); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("expired err = %v", err)
+	}
+
+	// 防回拨时钟记录的时间晚于到期时间，回拨系统时间后仍判为过期
+	clock := &Clock{}
+	clock.atLeast(time.Now().Add(48 * time.Hour))
+	if _, err := (&TokenVerifier{PublicKey: pub, MachineCode: "ABC123", Clock: clock}).Verify(token); !errors.Is(err, ErrTokenExpired) {
+		t.Fatalf("clock err = %v", err)
+	}
+
+	tampered := strings.Replace(token, token[10:12], "xx", 1)
+	if _, err := (&TokenVerifier{PublicKey: pub, MachineCode: "ABC123"}).Verify(tampered); err == nil {
+		t.Fatal("expected tampered token to fail")
+	}
+}
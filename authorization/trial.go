@@ -0,0 +1,278 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-23 09:48:31
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-23 17:26:14
+ * @Description:试用期
+ */
+package authorization
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/2Kil/tkstar/text"
+)
+
+var (
+	ErrTrialExpired  = errors.New("authorization: trial expired")
+	ErrTrialTampered = errors.New("authorization: trial markers tampered")
+)
+
+// DefaultTrialDays 未设置 Trial.Days 时的试用天数
+const DefaultTrialDays = 14
+
+// Trial 试用期，首次运行时把安装时间写入多个加密标记文件，并在授权缓存中保存一份
+// 标记与机器码绑定，缺失的标记（例如缓存目录被清理）用其余一致的标记静默补写；
+// 标记损坏或彼此不一致时报告篡改，篡改记录会写回标记，之后一直拒绝试用；
+// 标记文件和缓存中的副本全部删除时无法与首次运行区分
+type Trial struct {
+	Product string   // 产品名，用于派生默认标记路径和密钥
+	Machine string   // 机器码，通常为 hardware.SysSerialKey()
+	Days    int      // 试用天数，默认 DefaultTrialDays
+	Key     string   // 加密口令，为空时使用 Product
+	Paths   []string // 标记文件路径，为空使用 DefaultTrialPaths(Product)
+	Clock   *Clock   // 可选，用于检测系统时间回拨；通过 Client.SetTrial 使用时默认为客户端的 Clock
+	Cache   *Cache   // 可选，额外保存安装时间的授权缓存；通过 Client.SetTrial 使用时默认为客户端的缓存
+}
+
+// TrialState 试用期状态
+type TrialState struct {
+	InstalledAt time.Time     // 首次运行时间
+	ExpiresAt   time.Time     // 试用到期时间
+	Remaining   time.Duration // 剩余时长，已过期时为 0
+	DaysLeft    int           // 剩余天数（向上取整）
+	Expired     bool          // 试用已结束
+	FirstRun    bool          // 本次为首次运行
+	// Tampered 标记损坏或不一致（本次或以前发现），已用最早的安装时间补写；只是缺失不算篡改
+	// 全部标记损坏时 InstalledAt 为零值，按已过期处理；Client 遇到篡改时拒绝试用
+	Tampered      bool
+	ClockTampered bool // 系统时间早于安装时间或 Clock 检测到回拨
+}
+
+// trialMarker 标记文件内容
+type trialMarker struct {
+	Machine     string    `json:"m"`
+	InstalledAt time.Time `json:"t"`
+	Tampered    bool      `json:"x,omitempty"` // 曾发现篡改
+}
+
+// DefaultTrialPaths 返回默认标记路径：用户配置目录、缓存目录和主目录下各一个，文件名由产品名派生
+func DefaultTrialPaths(product string) []string {
+	name := func(i int) string {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("tkstar/trial/%s/%d", product, i)))
+		return hex.EncodeToString(sum[:])[:12]
+	}
+	var paths []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "."+name(0), name(1)+".dat"))
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "."+name(2), name(3)+".dat"))
+	}
+	if dir, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "."+name(4)))
+	}
+	return paths
+}
+
+// Status 读取试用状态，首次运行时写入标记，发现标记缺失时用最早的安装时间补写
+func (t *Trial) Status() (*TrialState, error) {
+	return t.status(t.Cache, t.Clock)
+}
+
+// status 同 Status，cache 为额外保存安装时间的授权缓存，clock 为防回拨时钟，均可为空
+func (t *Trial) status(cache *Cache, clock *Clock) (*TrialState, error) {
+	if t.Machine == "" {
+		return nil, errors.New("authorization: empty machine code")
+	}
+	paths := t.Paths
+	if len(paths) == 0 {
+		paths = DefaultTrialPaths(t.Product)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("authorization: no trial marker path")
+	}
+
+	now := time.Now()
+	clockTampered := false
+	if clock != nil {
+		var err error
+		now, err = clock.Now()
+		clockTampered = errors.Is(err, ErrClockTampered)
+	}
+
+	var installed time.Time
+	found, missing, broken := 0, 0, 0
+	tampered := false
+	add := func(m trialMarker) {
+		if m.Machine != t.Machine || m.InstalledAt.IsZero() {
+			broken++
+			return
+		}
+		if found > 0 && !m.InstalledAt.Equal(installed) {
+			broken++ // 各标记时间不一致
+		}
+		if found == 0 || m.InstalledAt.Before(installed) {
+			installed = m.InstalledAt
+		}
+		tampered = tampered || m.Tampered
+		found++
+	}
+	for _, path := range paths {
+		var m trialMarker
+		err := readSealedJSON(path, t.aesKey(), &m)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			missing++
+		case err != nil:
+			broken++
+		default:
+			add(m)
+		}
+	}
+	// 缓存中的副本：旧版本没有写入或缓存被清空时只补写，不视为篡改
+	anchored := false
+	if cache != nil {
+		if val, ok := cache.anchor(t.anchorName()); ok {
+			var m trialMarker
+			if err := t.unsealAnchor(val, &m); err != nil {
+				broken++
+			} else {
+				add(m)
+				anchored = true
+			}
+		}
+	}
+
+	s := &TrialState{ClockTampered: clockTampered}
+	switch {
+	case found == 0 && broken == 0:
+		s.FirstRun = true
+		installed = now
+	case found == 0:
+		// 所有标记都已损坏，无法恢复安装时间，按已过期处理
+		s.Tampered, s.Expired = true, true
+		return s, nil
+	default:
+		s.Tampered = tampered || broken > 0
+	}
+	if s.FirstRun || s.Tampered != tampered || missing > 0 || broken > 0 || (cache != nil && !anchored) {
+		marker := trialMarker{Machine: t.Machine, InstalledAt: installed, Tampered: s.Tampered}
+		for _, path := range paths {
+			if err := writeSealedJSON(path, t.aesKey(), &marker); err != nil {
+				log.Println("Error writing trial marker:", err)
+			}
+		}
+		if cache != nil {
+			if err := t.sealAnchor(cache, &marker); err != nil {
+				log.Println("Error writing trial marker to cache:", err)
+			}
+		}
+	}
+
+	days := t.Days
+	if days <= 0 {
+		days = DefaultTrialDays
+	}
+	s.InstalledAt = installed
+	s.ExpiresAt = installed.AddDate(0, 0, days)
+	if now.Before(installed) {
+		s.ClockTampered = true
+	}
+	if !now.Before(s.ExpiresAt) {
+		s.Expired = true
+		return s, nil
+	}
+	s.Remaining = s.ExpiresAt.Sub(now)
+	s.DaysLeft = int((s.Remaining + 24*time.Hour - 1) / (24 * time.Hour))
+	return s, nil
+}
+
+// aesKey 密钥由口令和机器码派生，复制到其他机器的标记无法解密
+func (t *Trial) aesKey() string {
+	key := t.Key
+	if key == "" {
+		key = t.Product
+	}
+	sum := sha256.Sum256([]byte("tkstar/trial\x00" + key + "\x00" + t.Machine))
+	return string(sum[:])
+}
+
+// anchorName 试用标记在授权缓存中的锚点名，不同产品和机器互不影响
+func (t *Trial) anchorName() string {
+	sum := sha256.Sum256([]byte(t.aesKey()))
+	return "trial/" + hex.EncodeToString(sum[:8])
+}
+
+// sealAnchor 以试用期密钥加密后写入缓存，缓存口令泄露也无法改写安装时间
+func (t *Trial) sealAnchor(cache *Cache, m *trialMarker) error {
+	plain, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	sealed, err := text.TextAesGcmEncrypt(string(plain), t.aesKey())
+	if err != nil {
+		return err
+	}
+	return cache.setAnchor(t.anchorName(), sealed)
+}
+
+func (t *Trial) unsealAnchor(val string, m *trialMarker) error {
+	plain, err := text.TextAesGcmDecrypt(val, t.aesKey())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(plain), m)
+}
+
+// SetTrial 设置试用期，序列号不在授权表中或拉取失败时按试用期判断，传 nil 关闭
+func (c *Client) SetTrial(t *Trial) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trial = t
+}
+
+// applyTrial 付费授权未找到或拉取失败时，用试用期状态填充结果
+func (c *Client) applyTrial(r *Result) {
+	c.mu.Lock()
+	trial := c.trial
+	c.mu.Unlock()
+	if trial == nil || !(errors.Is(r.Err, ErrNotFound) || errors.Is(r.Err, ErrFetchFailed)) {
+		return
+	}
+	cache, clock := trial.Cache, trial.Clock
+	c.mu.Lock()
+	if cache == nil {
+		cache = c.cache
+	}
+	if clock == nil {
+		clock = c.clock
+	}
+	c.mu.Unlock()
+	state, err := trial.status(cache, clock)
+	if err != nil {
+		log.Println("Error reading trial state:", err)
+		return
+	}
+	r.Trial = state
+	r.Expires = state.ExpiresAt
+	switch {
+	case state.ClockTampered || state.Tampered || state.InstalledAt.IsZero():
+		r.ClockTampered = r.ClockTampered || state.ClockTampered
+		r.Err = fmt.Errorf("%w: %w", ErrTrialTampered, r.Err)
+	case state.Expired:
+		r.Err = fmt.Errorf("%w: %w", ErrTrialExpired, r.Err)
+	default:
+		r.Valid, r.Err = true, nil
+		r.Remaining = state.Remaining
+		r.Source = "trial"
+	}
+}
@@ -0,0 +1,169 @@
+package authorization
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestTrial(t *testing.T) *Trial {
+	dir := t.TempDir()
+	return &Trial{
+		Product: "demo",
+		Machine: "MACHINE-1",
+		Days:    7,
+		Paths:   []string{filepath.Join(dir, "a", "x.dat"), filepath.Join(dir, "b", "y.dat"), filepath.Join(dir, ".z")},
+	}
+}
+
+// backdate 把所有标记改写为指定的安装时间
+func backdate(t *testing.T, trial *Trial, installed time.Time) {
+	for _, path := range trial.Paths {
+		if err := writeSealedJSON(path, trial.aesKey(), &trialMarker{Machine: trial.Machine, InstalledAt: installed}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTrialFirstRunAndDeletedMarker(t *testing.T) {
+	trial := newTestTrial(t)
+	s, err := trial.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.FirstRun || s.Expired || s.Tampered || s.DaysLeft != 7 {
+		t.Fatalf("first run = %+v", s)
+	}
+
+	installed := time.Now().Add(-3 * 24 * time.Hour).Truncate(time.Second)
+	backdate(t, trial, installed)
+	if err := os.Remove(trial.Paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	s, err = trial.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.FirstRun || s.Tampered || !s.InstalledAt.Equal(installed) || s.DaysLeft != 4 {
+		t.Fatalf("after delete = %+v", s)
+	}
+	// 缓存目录被清理只是缺失，已静默补写
+	if _, err := os.Stat(trial.Paths[0]); err != nil {
+		t.Fatalf("marker not restored: %v", err)
+	}
+
+	// 损坏的标记视为篡改，篡改记录写回所有标记
+	if err := os.WriteFile(trial.Paths[1], []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := trial.Status(); !s.Tampered || !s.InstalledAt.Equal(installed) {
+		t.Fatalf("after corrupt = %+v", s)
+	}
+	if s, _ := trial.Status(); !s.Tampered {
+		t.Fatalf("after restore = %+v", s)
+	}
+
+	// 标记复制到其他机器无法使用
+	other := *trial
+	other.Machine = "MACHINE-2"
+	if s, _ := other.Status(); !s.Tampered || !s.Expired || !s.InstalledAt.IsZero() {
+		t.Fatalf("other machine = %+v", s)
+	}
+}
+
+func TestTrialIntegratesWithCheckResult(t *testing.T) {
+	trial := newTestTrial(t)
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "PAID", Time: "2099-01-01"}}})
+	client.SetTrial(trial)
+
+	r := client.CheckAccreditDetailed("")
+	if !r.Valid || r.Trial == nil || r.Source != "trial" || r.Trial.DaysLeft != 7 {
+		t.Fatalf("trial result = %+v", r)
+	}
+	if r := client.CheckAccreditDetailed("PAID"); !r.Valid || r.Trial != nil {
+		t.Fatalf("paid result = %+v", r)
+	}
+
+	backdate(t, trial, time.Now().Add(-8*24*time.Hour))
+	r = client.CheckAccreditDetailed("")
+	if r.Valid || !errors.Is(r.Err, ErrTrialExpired) || !errors.Is(r.Err, ErrNotFound) {
+		t.Fatalf("expired trial result = %+v", r)
+	}
+
+	backdate(t, trial, time.Now().Add(24*time.Hour))
+	r = client.CheckAccreditDetailed("")
+	if r.Valid || !r.ClockTampered || !errors.Is(r.Err, ErrTrialTampered) {
+		t.Fatalf("rolled back result = %+v", r)
+	}
+}
+
+func TestTrialCacheAnchorSurvivesDeletedMarkers(t *testing.T) {
+	trial := newTestTrial(t)
+	cache := &Cache{Path: filepath.Join(t.TempDir(), "license.cache"), TTL: time.Hour}
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "PAID", Time: "2099-01-01"}}})
+	client.SetCache(cache)
+	client.SetTrial(trial)
+
+	if r := client.CheckAccreditDetailed(""); !r.Valid || !r.Trial.FirstRun {
+		t.Fatalf("first run = %+v", r)
+	}
+	// 刷新授权数据时保留缓存中的试用标记
+	if _, err := client.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if e, err := cache.Load(); err != nil || len(e.Data) != 1 || len(e.Anchors) != 1 {
+		t.Fatalf("cache = %+v, %v", e, err)
+	}
+
+	for _, path := range trial.Paths {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := client.CheckAccreditDetailed("")
+	if !r.Valid || r.Trial.FirstRun || r.Trial.Tampered {
+		t.Fatalf("after deleting markers = %+v", r)
+	}
+	// 标记已从缓存恢复，安装时间不变
+	s, err := trial.Status()
+	if err != nil || s.Tampered || s.FirstRun || !s.InstalledAt.Equal(r.Trial.InstalledAt) {
+		t.Fatalf("restored = %+v, %v", s, err)
+	}
+	for _, path := range trial.Paths {
+		if _, err := os.Stat(path); err != nil {
+			t.Fatalf("marker not restored: %v", err)
+		}
+	}
+}
+
+func TestTrialAddsCacheAnchorWithoutTamper(t *testing.T) {
+	trial := newTestTrial(t)
+	if _, err := trial.Status(); err != nil {
+		t.Fatal(err)
+	}
+	// 升级后首次使用缓存：只补写，不视为篡改
+	trial.Cache = &Cache{Path: filepath.Join(t.TempDir(), "license.cache")}
+	for i := 0; i < 2; i++ {
+		if s, err := trial.Status(); err != nil || s.Tampered || s.FirstRun {
+			t.Fatalf("status = %+v, %v", s, err)
+		}
+	}
+	if _, ok := trial.Cache.anchor(trial.anchorName()); !ok {
+		t.Fatal("anchor not written")
+	}
+}
+
+func TestTrialUsesClientClock(t *testing.T) {
+	trial := newTestTrial(t)
+	backdate(t, trial, time.Now().Add(-3*24*time.Hour))
+	clock := &Clock{}
+	clock.atLeast(time.Now().Add(10 * 24 * time.Hour))
+	client := NewSourceClient(&stubSource{name: "stub"})
+	client.SetClock(clock)
+	client.SetTrial(trial)
+	if r := client.CheckAccreditDetailed(""); r.Valid || r.Trial == nil || !r.Trial.Expired {
+		t.Fatalf("trial result = %+v", r)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-13 09:15:52
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-13 16:08:27
+ * @Description:授权后台监控
+ */
+package authorization
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType 监控事件类型
+type EventType int
+
+const (
+	EventValid        EventType = iota // 授权有效（首次检查或从其他状态恢复）
+	EventExpiringSoon                  // 即将过期
+	EventExpired                       // 已过期
+	EventRevoked                       // 序列号从授权表中消失或被吊销列表吊销
+	EventInvalid                       // 未找到或到期时间无法解析
+	EventUnreachable                   // 所有数据源不可达，继续使用已有数据判断
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventValid:
+		return "valid"
+	case EventExpiringSoon:
+		return "expiring_soon"
+	case EventExpired:
+		return "expired"
+	case EventRevoked:
+		return "revoked"
+	case EventInvalid:
+		return "invalid"
+	case EventUnreachable:
+		return "unreachable"
+	}
+	return "unknown"
+}
+
+// Event 监控事件
+type Event struct {
+	Type   EventType
+	Time   time.Time
+	Result *Result // 本次校验结果
+	Err    error   // EventUnreachable 时为拉取错误
+}
+
+// WatchOptions 监控参数
+type WatchOptions struct {
+	Interval       time.Duration // 刷新间隔，默认 1 小时
+	ExpiringWithin time.Duration // 剩余时长低于该值时触发 EventExpiringSoon，默认 7 天
+}
+
+// Watcher 后台定期刷新授权表并重新评估指定序列号
+type Watcher struct {
+	client *Client
+	key    string
+	opts   WatchOptions
+	onEvt  func(Event)
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	once    sync.Once
+	calling atomic.Bool // 正在执行回调
+
+	// 以下字段只在监控协程内访问
+	last  EventType
+	seen  bool // 是否曾在授权表中找到过该序列号
+	fired bool // 是否已发出过状态事件
+}
+
+// Watch 启动后台监控，状态变化时回调 fn，fn 在监控协程中串行调用，可在 fn 中调用 Stop
+// 启动时立即检查一次，之后每隔 Interval 强制刷新授权表
+func (c *Client) Watch(key string, opts WatchOptions, fn func(Event)) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Hour
+	}
+	if opts.ExpiringWithin <= 0 {
+		opts.ExpiringWithin = 7 * 24 * time.Hour
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Watcher{
+		client: c,
+		key:    key,
+		opts:   opts,
+		onEvt:  fn,
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go w.run(ctx)
+	return w
+}
+
+// Stop 停止监控并等待协程退出，可重复调用；Stop 返回后不会再有新的回调
+// 回调执行期间调用（包括在回调中调用）只取消不等待，避免死锁
+func (w *Watcher) Stop() {
+	w.once.Do(w.cancel)
+	if w.calling.Load() {
+		return
+	}
+	<-w.done
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+
+	// 首次检查允许使用内存数据或离线缓存
+	w.emitState(w.client.CheckAccreditDetailedContext(ctx, w.key))
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.tick(ctx)
+		}
+	}
+}
+
+// tick 强制刷新授权表并重新评估
+// 拉取失败且校验结果本身也是 ErrFetchFailed 时，由 emitState 发出 EventUnreachable，避免重复
+func (w *Watcher) tick(ctx context.Context) {
+	_, err := w.client.refresh(ctx)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	r := w.client.CheckAccreditDetailedContext(ctx, w.key)
+	if err != nil && !errors.Is(r.Err, ErrFetchFailed) {
+		w.emit(Event{Type: EventUnreachable, Time: time.Now(), Err: err})
+	}
+	w.emitState(r)
+}
+
+// emitState 将校验结果归类为状态事件，仅在状态变化时回调
+func (w *Watcher) emitState(r *Result) {
+	var t EventType
+	switch {
+	case r.Valid && r.Remaining <= w.opts.ExpiringWithin:
+		t = EventExpiringSoon
+	case r.Valid:
+		t = EventValid
+	case errors.Is(r.Err, ErrExpired):
+		t = EventExpired
+	case errors.Is(r.Err, ErrNotFound) && w.seen, errors.Is(r.Err, ErrRevoked):
+		t = EventRevoked
+	case errors.Is(r.Err, ErrFetchFailed):
+		w.emit(Event{Type: EventUnreachable, Time: time.Now(), Result: r, Err: r.Err})
+		return
+	default:
+		t = EventInvalid
+	}
+	if !errors.Is(r.Err, ErrNotFound) {
+		w.seen = true
+	}
+
+	if w.fired && w.last == t {
+		return
+	}
+	w.fired = true
+	w.last = t
+	w.emit(Event{Type: t, Time: time.Now(), Result: r})
+}
+
+func (w *Watcher) emit(e Event) {
+	if w.onEvt == nil || w.ctx.Err() != nil {
+		return
+	}
+	w.calling.Store(true)
+	defer w.calling.Store(false)
+	w.onEvt(e)
+}
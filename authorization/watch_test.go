@@ -0,0 +1,112 @@
+package authorization
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mutableSource struct {
+	mu   sync.Mutex
+	data []Accredit
+	err  error
+}
+
+func (s *mutableSource) Name() string { return "mutable" }
+
+func (s *mutableSource) Fetch(ctx context.Context) ([]Accredit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cloneAccredits(s.data), s.err
+}
+
+func (s *mutableSource) set(data []Accredit, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data, s.err = data, err
+}
+
+func TestWatcherEmitsRevokedAndUnreachable(t *testing.T) {
+	src := &mutableSource{data: []Accredit{{Sn: "A", Time: "2099-01-01"}}}
+	client := NewSourceClient(src)
+
+	events := make(chan Event, 16)
+	w := client.Watch("A", WatchOptions{Interval: 10 * time.Millisecond}, func(e Event) { events <- e })
+	defer w.Stop()
+
+	wait := func(want EventType) {
+		t.Helper()
+		deadline := time.After(2 * time.Second)
+		for {
+			select {
+			case e := <-events:
+				if e.Type == want {
+					return
+				}
+			case <-deadline:
+				t.Fatalf("timed out waiting for %s", want)
+			}
+		}
+	}
+
+	wait(EventValid)
+	src.set(nil, errors.New("offline"))
+	wait(EventUnreachable)
+	src.set([]Accredit{{Sn: "B", Time: "2099-01-01"}}, nil)
+	wait(EventRevoked)
+
+	// CheckAccredit 可与监控并发调用
+	if client.CheckAccredit("A") {
+		t.Fatal("revoked key still valid")
+	}
+}
+
+func TestWatcherExpiringSoon(t *testing.T) {
+	soon := time.Now().Add(24 * time.Hour).Format("2006-01-02 15:04:05")
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "A", Time: soon}}})
+
+	events := make(chan Event, 4)
+	w := client.Watch("A", WatchOptions{Interval: time.Hour, ExpiringWithin: 48 * time.Hour}, func(e Event) { events <- e })
+	select {
+	case e := <-events:
+		if e.Type != EventExpiringSoon {
+			t.Fatalf("event = %s", e.Type)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+	w.Stop()
+	w.Stop()
+}
+
+func TestWatcherTickEmitsUnreachableOnce(t *testing.T) {
+	client := NewSourceClient(&stubSource{name: "down", err: errors.New("offline")})
+	var events []Event
+	ctx := context.Background()
+	w := &Watcher{client: client, key: "A", ctx: ctx, onEvt: func(e Event) { events = append(events, e) }}
+	w.tick(ctx)
+	if len(events) != 1 || events[0].Type != EventUnreachable {
+		t.Fatalf("events = %+v, want a single EventUnreachable", events)
+	}
+}
+
+func TestWatcherStopFromCallback(t *testing.T) {
+	client := NewSourceClient(&stubSource{name: "stub", data: []Accredit{{Sn: "A", Time: "2099-01-01"}}})
+	stopped := make(chan struct{})
+	var w *Watcher
+	ready := make(chan struct{})
+	w = client.Watch("A", WatchOptions{Interval: 10 * time.Millisecond}, func(e Event) {
+		<-ready
+		w.Stop()
+		close(stopped)
+	})
+	close(ready)
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop called from the callback deadlocked")
+	}
+	w.Stop() // 协程退出后再次调用会等待并立即返回
+}
@@ -0,0 +1,246 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-24 15:20:03
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-24 18:05:47
+ * @Description:激活工具，客户端和签发端共用
+ *
+ *	tkstar-activation keygen
+ *	tkstar-activation request -product demo -key 口令 -qr
+ *	tkstar-activation issue   -priv 私钥 -key 口令 -customer acme -days 365 tka1.xxx
+ *	tkstar-activation import  -product demo -machine ABC123 -pub 公钥 -path demo.key tk1.xxx
+ *	tkstar-activation verify  -product demo -machine ABC123 -pub 公钥 -path demo.key -revocations revocations.txt
+ *	tkstar-activation revoke  -priv 私钥 -version 3 -sn SN1,SN2 -mc ABC123 > revocations.txt
+ *
+ * -machine 省略时使用本机的 hardware.SysSerialKey()；import 和 verify 指定 -revocations 时检查吊销列表
+ * 私钥也可通过环境变量 TKSTAR_PRIVATE_KEY 传入；请求和令牌省略时从标准输入读取
+ */
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/2Kil/tkstar/authorization"
+	"github.com/2Kil/tkstar/hardware"
+	"github.com/2Kil/tkstar/text"
+)
+
+func main() {
+	log.SetFlags(0)
+	if len(os.Args) < 2 {
+		usage()
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+	switch cmd {
+	case "keygen":
+		keygen()
+	case "request":
+		request(args)
+	case "issue":
+		issue(args)
+	case "import":
+		importToken(args)
+	case "verify":
+		verify(args)
+	case "revoke":
+		revoke(args)
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	log.Fatalln("usage: tkstar-activation keygen|request|issue|import|verify|revoke [flags]")
+}
+
+func keygen() {
+	pub, priv, err := authorization.GenerateTokenKey()
+	if err != nil {
+		log.Fatalln("Error generating key:", err)
+	}
+	fmt.Println("public: ", authorization.EncodeKey(pub))
+	fmt.Println("private:", authorization.EncodeKey(priv))
+}
+
+func request(args []string) {
+	fs := flag.NewFlagSet("request", flag.ExitOnError)
+	a := clientFlags(fs)
+	key := fs.String("key", "", "激活请求口令")
+	version := fs.String("version", "", "产品版本")
+	qr := fs.Bool("qr", false, "同时以二维码显示，便于手机扫描")
+	fs.Parse(args)
+	defaultMachine(a)
+
+	a.RequestKey, a.Version = *key, *version
+	blob, err := a.Request()
+	if err != nil {
+		log.Fatalln("Error creating request:", err)
+	}
+	fmt.Println(blob)
+	if *qr {
+		code, err := text.TextQRCodeTerminal(blob)
+		if err != nil {
+			log.Fatalln("Error creating qr code:", err)
+		}
+		fmt.Print(code)
+	}
+}
+
+func issue(args []string) {
+	fs := flag.NewFlagSet("issue", flag.ExitOnError)
+	privText := fs.String("priv", os.Getenv("TKSTAR_PRIVATE_KEY"), "签发私钥")
+	key := fs.String("key", "", "激活请求口令")
+	customer := fs.String("customer", "", "客户标识")
+	features := fs.String("features", "", "功能列表，逗号分隔")
+	days := fs.Int("days", 0, "有效天数，0 表示永久")
+	out := fs.String("out", "", "令牌输出文件，为空时打印")
+	fs.Parse(args)
+
+	priv, err := authorization.ParsePrivateKey(*privText)
+	if err != nil {
+		log.Fatalln("Error parsing private key:", err)
+	}
+	lic := authorization.License{Customer: *customer, Features: splitList(*features)}
+	if *days > 0 {
+		lic.ExpiresAt = time.Now().AddDate(0, 0, *days)
+	}
+	issuer := &authorization.Issuer{PrivateKey: priv, RequestKey: *key}
+	token, req, err := issuer.Activate(argOrStdin(fs), lic)
+	if err != nil {
+		log.Fatalln("Error issuing token:", err)
+	}
+	log.Printf("issued for %s %s machine %s", req.Product, req.Version, req.MachineCode)
+	if *out == "" {
+		fmt.Println(token)
+		return
+	}
+	if err := os.WriteFile(*out, []byte(token+"\n"), 0o600); err != nil {
+		log.Fatalln("Error writing token:", err)
+	}
+}
+
+func importToken(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	a := clientFlags(fs)
+	pub := fs.String("pub", "", "签发公钥")
+	revocations := fs.String("revocations", "", "吊销列表文件，即 revoke 的输出")
+	fs.Parse(args)
+	defaultMachine(a)
+
+	a.PublicKey = parsePublicKey(*pub)
+	setRevocations(a, *revocations)
+	lic, err := a.Import(argOrStdin(fs))
+	if err != nil {
+		log.Fatalln("Error importing token:", err)
+	}
+	printLicense(lic)
+}
+
+func verify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	a := clientFlags(fs)
+	pub := fs.String("pub", "", "签发公钥")
+	revocations := fs.String("revocations", "", "吊销列表文件，即 revoke 的输出")
+	fs.Parse(args)
+	defaultMachine(a)
+
+	a.PublicKey = parsePublicKey(*pub)
+	setRevocations(a, *revocations)
+	lic, err := a.Load()
+	if err != nil {
+		log.Fatalln("Error verifying license:", err)
+	}
+	printLicense(lic)
+}
+
+// revoke 签发吊销列表，版本号需大于上一次发布的列表
+func revoke(args []string) {
+	fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+	privText := fs.String("priv", os.Getenv("TKSTAR_PRIVATE_KEY"), "签发私钥")
+	version := fs.Uint64("version", 0, "列表版本号")
+	serials := fs.String("sn", "", "吊销的序列号，逗号分隔")
+	machines := fs.String("mc", "", "吊销的机器码，逗号分隔")
+	fs.Parse(args)
+
+	priv, err := authorization.ParsePrivateKey(*privText)
+	if err != nil {
+		log.Fatalln("Error parsing private key:", err)
+	}
+	list := authorization.RevocationList{Version: *version, Serials: splitList(*serials), Machines: splitList(*machines)}
+	blob, err := authorization.SignRevocationList(priv, list)
+	if err != nil {
+		log.Fatalln("Error signing revocation list:", err)
+	}
+	fmt.Println(blob)
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// clientFlags 客户端子命令共用的参数，解析后写入返回的 Activator
+func clientFlags(fs *flag.FlagSet) *authorization.Activator {
+	a := &authorization.Activator{}
+	fs.StringVar(&a.Product, "product", "", "产品标识")
+	fs.StringVar(&a.Machine, "machine", "", "本机机器码，默认 hardware.SysSerialKey()")
+	fs.StringVar(&a.Path, "path", "license.key", "授权文件路径")
+	return a
+}
+
+// defaultMachine 未指定 -machine 时读取本机机器码，与程序中 hardware.SysSerialKey() 一致
+// 不受 VPN、Docker 等虚拟网卡启停影响
+func defaultMachine(a *authorization.Activator) {
+	if a.Machine != "" {
+		return
+	}
+	mc, err := hardware.SysSerialKey()
+	if err != nil {
+		log.Fatalln("Error reading machine code:", err)
+	}
+	a.Machine = mc
+}
+
+// setRevocations 指定 -revocations 时校验令牌前检查吊销列表，文件损坏或签名不符时校验失败
+func setRevocations(a *authorization.Activator, path string) {
+	if path != "" {
+		a.Revocations = &authorization.Revocations{PublicKey: a.PublicKey, Path: path}
+	}
+}
+
+func parsePublicKey(s string) []byte {
+	pub, err := authorization.ParsePublicKey(s)
+	if err != nil {
+		log.Fatalln("Error parsing public key:", err)
+	}
+	return pub
+}
+
+// argOrStdin 返回第一个位置参数，没有时读取标准输入
+func argOrStdin(fs *flag.FlagSet) string {
+	if fs.NArg() > 0 {
+		return fs.Arg(0)
+	}
+	raw, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatalln("Error reading stdin:", err)
+	}
+	return string(raw)
+}
+
+func printLicense(lic *authorization.License) {
+	raw, _ := json.MarshalIndent(lic, "", "  ")
+	fmt.Println(string(raw))
+}
@@ -0,0 +1,49 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-21 15:02:37
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-21 17:41:09
+ * @Description:自建授权服务，可部署在客户内网
+ *
+ *	tkstar-licserver -addr :8520 -data licenses.json -admin-token xxx -lease-ttl 2m
+ *
+ * 令牌也可通过环境变量 TKSTAR_ADMIN_TOKEN、TKSTAR_READ_TOKEN 传入，避免出现在进程列表中
+ */
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/2Kil/tkstar/authorization/licserver"
+)
+
+func main() {
+	addr := flag.String("addr", ":8520", "监听地址")
+	data := flag.String("data", "licenses.json", "授权存储文件")
+	adminToken := flag.String("admin-token", os.Getenv("TKSTAR_ADMIN_TOKEN"), "管理接口令牌，为空时禁用管理接口")
+	readToken := flag.String("read-token", os.Getenv("TKSTAR_READ_TOKEN"), "读取授权列表的令牌，为空时无需认证")
+	leaseTTL := flag.Duration("lease-ttl", licserver.DefaultLeaseTTL, "席位租约有效期，客户端需在此之前续约")
+	flag.Parse()
+
+	store, err := licserver.OpenStore(*data)
+	if err != nil {
+		log.Fatalln("Error opening store:", err)
+	}
+	if *adminToken == "" {
+		log.Println("admin token not set, admin api disabled")
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           licserver.New(store, licserver.Options{AdminToken: *adminToken, ReadToken: *readToken, LeaseTTL: *leaseTTL}),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+	log.Printf("license server listening on %s, data %s", *addr, *data)
+	log.Fatal(srv.ListenAndServe())
+}
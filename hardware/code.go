@@ -0,0 +1,158 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-31 09:26:51
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-31 15:48:13
+ * @Description: 机器码格式：Crockford base32、版本前缀、分组和校验字符
+ */
+
+package hardware
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// crockford Crockford base32 字母表，不含 I、L、O、U
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// maxCodeLength SHA-256 最多可编码的字符数
+const maxCodeLength = 256 / 5
+
+var (
+	ErrCodeMalformed = errors.New("hardware: malformed machine code")
+	ErrCodeChecksum  = errors.New("hardware: machine code check character mismatch")
+	ErrCodeVersion   = errors.New("hardware: unsupported machine code version")
+)
+
+// CodeFormat 机器码格式
+// 机器码为 版本字符 + Length 个数据字符 + 校验字符，再按 Group 用 - 分组，例如 1K7QX-M3V9A-2PDR
+type CodeFormat struct {
+	Version int // 算法版本，0-31，编码为首字符
+	Length  int // 数据字符数，默认 12，最多 51
+	Group   int // 每组字符数，0 表示不分组
+}
+
+// DefaultCodeFormat 默认格式：版本 1，12 个数据字符，每 5 个字符一组
+var DefaultCodeFormat = CodeFormat{Version: 1, Length: 12, Group: 5}
+
+// Format 对 raw 做 SHA-256 后生成机器码
+func (f CodeFormat) Format(raw []byte) string {
+	sum := sha256.Sum256(raw)
+	n := f.length()
+	chars := make([]byte, 0, n+2)
+	chars = append(chars, crockford[f.Version&31])
+	for i := 0; i < n; i++ {
+		chars = append(chars, crockford[bits5(sum[:], i*5)])
+	}
+	chars = append(chars, checkChar(chars))
+	return f.group(string(chars))
+}
+
+// Parse 规范化用户输入的机器码并校验，返回标准格式
+// 忽略大小写、空白和 -，O 视为 0，I、L 视为 1
+func (f CodeFormat) Parse(s string) (string, error) {
+	chars := NormalizeCode(s)
+	if len(chars) != f.length()+2 {
+		return "", fmt.Errorf("%w: want %d characters, got %d", ErrCodeMalformed, f.length()+2, len(chars))
+	}
+	for i := 0; i < len(chars); i++ {
+		if strings.IndexByte(crockford, chars[i]) < 0 {
+			return "", fmt.Errorf("%w: invalid character %q", ErrCodeMalformed, chars[i])
+		}
+	}
+	if checkChar([]byte(chars[:len(chars)-1])) != chars[len(chars)-1] {
+		return "", ErrCodeChecksum
+	}
+	if v := strings.IndexByte(crockford, chars[0]); v != f.Version&31 {
+		return "", fmt.Errorf("%w: %d", ErrCodeVersion, v)
+	}
+	return f.group(chars), nil
+}
+
+// NormalizeCode 去掉分隔符并替换易混淆字符，不做校验
+func NormalizeCode(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		switch r {
+		case '-', ' ', '\t', '\r', '\n':
+		case 'O':
+			b.WriteByte('0')
+		case 'I', 'L':
+			b.WriteByte('1')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// CodeVersion 返回机器码的版本号，用于在多种格式之间选择 Parse 使用的格式
+func CodeVersion(s string) (int, error) {
+	chars := NormalizeCode(s)
+	if chars == "" {
+		return 0, ErrCodeMalformed
+	}
+	v := strings.IndexByte(crockford, chars[0])
+	if v < 0 {
+		return 0, ErrCodeMalformed
+	}
+	return v, nil
+}
+
+// Code 由指纹生成指定格式的机器码，组件不变时结果不变
+func (f *Fingerprint) Code(format CodeFormat) string {
+	if f.Len() == 0 {
+		return ""
+	}
+	return format.Format([]byte(f.canonical()))
+}
+
+func (f CodeFormat) length() int {
+	switch {
+	case f.Length <= 0:
+		return DefaultCodeFormat.Length
+	case f.Length > maxCodeLength:
+		return maxCodeLength
+	}
+	return f.Length
+}
+
+// group 每 Group 个字符插入一个 -
+func (f CodeFormat) group(chars string) string {
+	if f.Group <= 0 || len(chars) <= f.Group {
+		return chars
+	}
+	var b strings.Builder
+	for i := 0; i < len(chars); i += f.Group {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(chars[i:min(i+f.Group, len(chars))])
+	}
+	return b.String()
+}
+
+// bits5 取 data 中从第 off 位开始的 5 位
+func bits5(data []byte, off int) int {
+	v := 0
+	for i := off; i < off+5; i++ {
+		v = v<<1 | int(data[i/8]>>(7-i%8)&1)
+	}
+	return v
+}
+
+// checkChar Luhn mod 32 校验字符，可发现单字符错误和大部分相邻字符互换
+func checkChar(chars []byte) byte {
+	const n = len(crockford)
+	sum := 0
+	factor := 2
+	for i := len(chars) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(crockford, chars[i])
+		factor = 3 - factor
+		sum += addend/n + addend%n
+	}
+	return crockford[(n-sum%n)%n]
+}
@@ -0,0 +1,96 @@
+package hardware
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCodeFormat(t *testing.T) {
+	code := DefaultCodeFormat.Format([]byte("machine-a"))
+	if len(code) != 16 || strings.Count(code, "-") != 2 || code[0] != '1' {
+		t.Fatalf("code = %q, want version 1, 14 chars in groups of 5", code)
+	}
+	if again := DefaultCodeFormat.Format([]byte("machine-a")); again != code {
+		t.Errorf("not deterministic: %q vs %q", code, again)
+	}
+	if other := DefaultCodeFormat.Format([]byte("machine-b")); other == code {
+		t.Errorf("different input gave same code %q", code)
+	}
+
+	long := CodeFormat{Version: 2, Length: 20, Group: 4}.Format([]byte("machine-a"))
+	if len(strings.ReplaceAll(long, "-", "")) != 22 || long[0] != '2' {
+		t.Errorf("long code = %q", long)
+	}
+	flat := CodeFormat{Version: 1, Length: 12}.Format([]byte("machine-a"))
+	if flat != strings.ReplaceAll(code, "-", "") {
+		t.Errorf("ungrouped %q, grouped %q", flat, code)
+	}
+}
+
+func TestCodeParse(t *testing.T) {
+	code := DefaultCodeFormat.Format([]byte("machine-a"))
+
+	// 小写、空格、去掉分组都应被接受
+	loose := strings.ToLower(strings.ReplaceAll(code, "-", " "))
+	got, err := DefaultCodeFormat.Parse(loose)
+	if err != nil || got != code {
+		t.Fatalf("Parse(%q) = %q, %v; want %q", loose, got, err, code)
+	}
+
+	// 易混淆字符
+	if n := NormalizeCode("o0-iL 1"); n != "00111" {
+		t.Errorf("NormalizeCode = %q", n)
+	}
+
+	// 每个位置的单字符错误都应被发现
+	raw := NormalizeCode(code)
+	for i := 0; i < len(raw); i++ {
+		for _, c := range []byte(crockford) {
+			if c == raw[i] {
+				continue
+			}
+			typo := raw[:i] + string(c) + raw[i+1:]
+			if _, err := DefaultCodeFormat.Parse(typo); err == nil {
+				t.Fatalf("typo %q accepted", typo)
+			}
+		}
+	}
+
+	// 相邻字符互换
+	for i := 1; i+1 < len(raw)-1; i++ {
+		if raw[i] == raw[i+1] {
+			continue
+		}
+		swapped := raw[:i] + string(raw[i+1]) + string(raw[i]) + raw[i+2:]
+		if _, err := DefaultCodeFormat.Parse(swapped); !errors.Is(err, ErrCodeChecksum) {
+			t.Errorf("swap at %d: err = %v", i, err)
+		}
+	}
+
+	if _, err := DefaultCodeFormat.Parse(code[:len(code)-2]); !errors.Is(err, ErrCodeMalformed) {
+		t.Errorf("short code: err = %v", err)
+	}
+	if _, err := DefaultCodeFormat.Parse(strings.Replace(code, code[1:2], "U", 1)); !errors.Is(err, ErrCodeMalformed) {
+		t.Errorf("invalid char: err = %v", err)
+	}
+
+	v2 := CodeFormat{Version: 2, Length: 12, Group: 5}.Format([]byte("machine-a"))
+	if _, err := DefaultCodeFormat.Parse(v2); !errors.Is(err, ErrCodeVersion) {
+		t.Errorf("version mismatch: err = %v", err)
+	}
+	if v, err := CodeVersion(v2); err != nil || v != 2 {
+		t.Errorf("CodeVersion = %d, %v", v, err)
+	}
+}
+
+func TestFingerprintCode(t *testing.T) {
+	a := testFingerprint([]string{"00:00:00:00:00:01"}, "board", "disk", "cpu", "os")
+	code := a.Code(DefaultCodeFormat)
+	if _, err := DefaultCodeFormat.Parse(code); err != nil {
+		t.Fatalf("Parse(%q): %v", code, err)
+	}
+	if (&Fingerprint{}).Code(DefaultCodeFormat) != "" {
+		t.Error("empty fingerprint has code")
+	}
+}
@@ -0,0 +1,185 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-29 09:41:08
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-29 16:20:37
+ * @Description: 容错机器指纹，按组件分别哈希和比对
+ */
+
+package hardware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// 指纹组件名称
+const (
+	ComponentMAC   = "mac"   // 网卡 MAC 集合，任意一个仍存在即视为匹配
+	ComponentBoard = "board" // 主板/系统 UUID，Linux 上只有 root 可读，不采集
+	ComponentDisk  = "disk"  // 主硬盘序列号
+	ComponentCPU   = "cpu"   // CPU 标识
+	ComponentOS    = "os"    // 操作系统安装标识
+)
+
+// fingerprintVersion 组件哈希算法版本
+const fingerprintVersion = 1
+
+// ErrNoFingerprint 未能读取任何指纹组件
+var ErrNoFingerprint = errors.New("hardware: no fingerprint components available")
+
+// Fingerprint 机器指纹，只保存各组件的哈希，可序列化为 JSON 存入授权数据
+type Fingerprint struct {
+	Version    int               `json:"v"`
+	MACs       []string          `json:"mac,omitempty"` // 各 MAC 地址的哈希，已排序
+	Components map[string]string `json:"c,omitempty"`   // 组件名 -> 哈希，不含 mac
+}
+
+// NewFingerprint 由原始组件值生成指纹，空值会被忽略
+func NewFingerprint(macs []string, components map[string]string) *Fingerprint {
+	f := &Fingerprint{Version: fingerprintVersion, Components: map[string]string{}}
+	seen := map[string]bool{}
+	for _, mac := range macs {
+		mac = strings.ToUpper(strings.TrimSpace(mac))
+		if mac == "" {
+			continue
+		}
+		h := componentHash(ComponentMAC, mac)
+		if !seen[h] {
+			seen[h] = true
+			f.MACs = append(f.MACs, h)
+		}
+	}
+	sort.Strings(f.MACs)
+	for name, val := range components {
+		if val = strings.TrimSpace(val); val != "" && name != ComponentMAC {
+			f.Components[name] = componentHash(name, val)
+		}
+	}
+	return f
+}
+
+// CurrentFingerprint 读取本机指纹，部分组件读取失败时仍返回已读取的部分
+func CurrentFingerprint() (*Fingerprint, error) {
+	macs, components, err := fingerprintComponents()
+	f := NewFingerprint(macs, components)
+	if f.Len() == 0 {
+		if err == nil {
+			return nil, ErrNoFingerprint
+		}
+		return nil, errors.Join(ErrNoFingerprint, err)
+	}
+	return f, nil
+}
+
+// Len 返回指纹包含的组件数量，MAC 集合算一个
+func (f *Fingerprint) Len() int {
+	if f == nil {
+		return 0
+	}
+	n := len(f.Components)
+	if len(f.MACs) > 0 {
+		n++
+	}
+	return n
+}
+
+// ShortCode 由组件哈希派生 6 位显示用机器码，组件不变时结果不变
+// 与 SysGetSerialKey 的算法输入不同，两者的值不相同
+func (f *Fingerprint) ShortCode() string {
+	if f.Len() == 0 {
+		return ""
+	}
+	return shortCode(f.canonical())
+}
+
+// canonical 按固定顺序拼接全部组件哈希
+func (f *Fingerprint) canonical() string {
+	names := make([]string, 0, len(f.Components))
+	for name := range f.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(strings.Join(f.MACs, ","))
+	for _, name := range names {
+		b.WriteString("|" + name + "=" + f.Components[name])
+	}
+	return b.String()
+}
+
+// MatchResult 指纹比对结果
+type MatchResult struct {
+	OK      bool     // Matched 达到阈值
+	Matched int      // 仍然一致的组件数
+	Total   int      // 参与比对的组件数（以保存的指纹为准）
+	Drifted []string // 已变化或当前读取不到的组件，按名称排序
+}
+
+// Match 比对保存的指纹和当前指纹，至少 threshold 个组件一致时通过
+// threshold <= 0 时允许一个组件变化；保存的指纹中没有的组件不参与比对
+func Match(stored, current *Fingerprint, threshold int) MatchResult {
+	var r MatchResult
+	if stored == nil {
+		return r
+	}
+	if current == nil {
+		current = &Fingerprint{}
+	}
+	if len(stored.MACs) > 0 {
+		r.Total++
+		if intersects(stored.MACs, current.MACs) {
+			r.Matched++
+		} else {
+			r.Drifted = append(r.Drifted, ComponentMAC)
+		}
+	}
+	for name, h := range stored.Components {
+		r.Total++
+		if current.Components[name] == h {
+			r.Matched++
+		} else {
+			r.Drifted = append(r.Drifted, name)
+		}
+	}
+	sort.Strings(r.Drifted)
+
+	if threshold <= 0 {
+		threshold = max(r.Total-1, 1)
+	}
+	r.OK = r.Total > 0 && r.Matched >= threshold
+	return r
+}
+
+// componentHash 计算组件哈希，组件名参与计算，避免不同组件的相同值得到相同哈希
+func componentHash(name, val string) string {
+	sum := sha256.Sum256([]byte("tkstar-fp/" + name + "\x00" + val))
+	return hex.EncodeToString(sum[:8])
+}
+
+func intersects(a, b []string) bool {
+	set := make(map[string]bool, len(b))
+	for _, s := range b {
+		set[s] = true
+	}
+	for _, s := range a {
+		if set[s] {
+			return true
+		}
+	}
+	return false
+}
+
+// hardwareMACs 返回所有参与选择的网卡 MAC，排除虚拟网卡、网桥和隧道
+func hardwareMACs() []string {
+	var macs []string
+	for _, c := range MACCandidates() {
+		if c.Excluded == "" {
+			macs = append(macs, c.MAC)
+		}
+	}
+	return macs
+}
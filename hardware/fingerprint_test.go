@@ -0,0 +1,92 @@
+package hardware
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func testFingerprint(macs []string, board, disk, cpu, os string) *Fingerprint {
+	return NewFingerprint(macs, map[string]string{
+		ComponentBoard: board,
+		ComponentDisk:  disk,
+		ComponentCPU:   cpu,
+		ComponentOS:    os,
+	})
+}
+
+func TestNewFingerprint(t *testing.T) {
+	f := testFingerprint([]string{"aa:bb:cc:00:00:01", " AA:BB:CC:00:00:01 ", ""}, "UUID-1", "", "cpu", "os")
+	if len(f.MACs) != 1 {
+		t.Errorf("MACs = %v, want duplicates merged", f.MACs)
+	}
+	if _, ok := f.Components[ComponentDisk]; ok {
+		t.Error("empty disk component kept")
+	}
+	if f.Len() != 4 {
+		t.Errorf("Len = %d, want 4", f.Len())
+	}
+	if f.Components[ComponentBoard] == "UUID-1" {
+		t.Error("component stored in clear text")
+	}
+
+	raw, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Fingerprint
+	if err := json.Unmarshal(raw, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, &back) {
+		t.Errorf("round trip = %+v, want %+v", back, f)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	stored := testFingerprint([]string{"00:00:00:00:00:01", "00:00:00:00:00:02"}, "board", "disk", "cpu", "os")
+
+	tests := []struct {
+		name      string
+		current   *Fingerprint
+		threshold int
+		ok        bool
+		drifted   []string
+	}{
+		{"same", testFingerprint([]string{"00:00:00:00:00:02", "00:00:00:00:00:01"}, "board", "disk", "cpu", "os"), 0, true, nil},
+		{"one nic swapped", testFingerprint([]string{"00:00:00:00:00:01", "00:00:00:00:00:09"}, "board", "disk", "cpu", "os"), 5, true, nil},
+		{"disk replaced", testFingerprint([]string{"00:00:00:00:00:01"}, "board", "disk2", "cpu", "os"), 0, true, []string{ComponentDisk}},
+		{"two drifted default", testFingerprint([]string{"00:00:00:00:00:09"}, "board", "disk2", "cpu", "os"), 0, false, []string{ComponentDisk, ComponentMAC}},
+		{"two drifted threshold 3", testFingerprint([]string{"00:00:00:00:00:09"}, "board", "disk2", "cpu", "os"), 3, true, []string{ComponentDisk, ComponentMAC}},
+		{"component missing", testFingerprint([]string{"00:00:00:00:00:01"}, "", "disk", "cpu", "os"), 0, true, []string{ComponentBoard}},
+		{"nil current", nil, 1, false, []string{ComponentBoard, ComponentCPU, ComponentDisk, ComponentMAC, ComponentOS}},
+	}
+	for _, tt := range tests {
+		r := Match(stored, tt.current, tt.threshold)
+		if r.OK != tt.ok || !reflect.DeepEqual(r.Drifted, tt.drifted) {
+			t.Errorf("%s: got ok=%v drifted=%v, want ok=%v drifted=%v", tt.name, r.OK, r.Drifted, tt.ok, tt.drifted)
+		}
+		if r.Total != 5 {
+			t.Errorf("%s: Total = %d, want 5", tt.name, r.Total)
+		}
+	}
+
+	if r := Match(nil, stored, 0); r.OK {
+		t.Error("nil stored matched")
+	}
+}
+
+func TestShortCode(t *testing.T) {
+	a := testFingerprint([]string{"00:00:00:00:00:01"}, "board", "disk", "cpu", "os")
+	b := testFingerprint([]string{"00:00:00:00:00:01"}, "board", "disk", "cpu", "os")
+	c := testFingerprint([]string{"00:00:00:00:00:01"}, "board", "disk2", "cpu", "os")
+	if len(a.ShortCode()) != 6 || a.ShortCode() != b.ShortCode() {
+		t.Errorf("short codes %q %q, want stable 6 chars", a.ShortCode(), b.ShortCode())
+	}
+	if a.ShortCode() == c.ShortCode() {
+		t.Error("different components gave same short code")
+	}
+	if (&Fingerprint{}).ShortCode() != "" {
+		t.Error("empty fingerprint has short code")
+	}
+}
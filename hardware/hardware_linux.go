@@ -0,0 +1,210 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-28 14:06:19
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-28 17:32:45
+ * @Description: 硬件相关（Linux），从 /etc 和 /sys 读取
+ */
+
+package hardware
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// virtualBlockPrefixes 不对应物理硬盘的块设备
+var virtualBlockPrefixes = []string{"loop", "ram", "zram", "dm-", "md", "sr", "nbd", "fd"}
+
+// platformIDs 读取系统 UUID 和第一块物理硬盘的序列号
+// product_uuid 通常只有 root 可读，为使普通用户和 root 得到相同的机器码，优先使用 machine-id
+func platformIDs() (uuid, diskSerial string, err error) {
+	var errs []error
+	uuid, e := machineID()
+	if e != nil {
+		errs = append(errs, e)
+		uuid, e = readID("sys/class/dmi/id/product_uuid")
+		if e != nil {
+			errs = append(errs, e)
+		}
+	}
+	diskSerial, e = blockSerial()
+	if e != nil {
+		errs = append(errs, e)
+	}
+	return strings.ToUpper(uuid), diskSerial, errors.Join(errs...)
+}
+
+// legacyDefaults Linux 不代入默认值
+func legacyDefaults(uuid, diskSerial string) (string, string) {
+	return uuid, diskSerial
+}
+
+// cpuinfoKeys 组成 CPU 标识的 /proc/cpuinfo 字段，x86 和 ARM 各取其一；不含会随微码更新变化的字段
+var cpuinfoKeys = []string{"vendor_id", "cpu family", "model", "model name", "stepping", "Serial", "CPU implementer", "CPU architecture", "CPU variant", "CPU part", "CPU revision"}
+
+// fingerprintComponents 读取指纹的原始组件
+// product_uuid 和 board_serial 只有 root 可读，为使 root 和普通用户得到相同的组件，Linux 不采集 board
+func fingerprintComponents() (macs []string, components map[string]string, err error) {
+	var errs []error
+	components = map[string]string{}
+	add := func(name string, val string, e error) {
+		if e != nil {
+			errs = append(errs, e)
+			return
+		}
+		components[name] = val
+	}
+	disk, e := blockSerial()
+	add(ComponentDisk, disk, e)
+	cpu, e := cpuSignature()
+	add(ComponentCPU, cpu, e)
+	id, e := machineID()
+	add(ComponentOS, id, e)
+	return hardwareMACs(), components, errors.Join(errs...)
+}
+
+// cpuSignature 由第一个处理器的型号字段组成 CPU 标识
+func cpuSignature() (string, error) {
+	f, err := os.Open(sysPath("proc/cpuinfo"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fields := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" {
+			if len(fields) > 0 {
+				break // 只读第一个处理器
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(line, ":")
+		if ok {
+			fields[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	var parts []string
+	for _, k := range cpuinfoKeys {
+		if v := fields[k]; v != "" {
+			parts = append(parts, k+"="+v)
+		}
+	}
+	if len(parts) == 0 {
+		return "", errors.New("hardware: no cpu identification in cpuinfo")
+	}
+	return strings.Join(parts, "|"), nil
+}
+
+// machineID 读取 systemd 或 dbus 的 machine-id
+func machineID() (string, error) {
+	id, err := readID("etc/machine-id")
+	if err == nil {
+		return id, nil
+	}
+	if id, e := readID("var/lib/dbus/machine-id"); e == nil {
+		return id, nil
+	}
+	return "", err
+}
+
+// blockSerial 按设备名顺序返回第一块物理硬盘的序列号
+func blockSerial() (string, error) {
+	names, err := physicalBlocks()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		if s := blockDeviceSerial(name); s != "" {
+			return s, nil
+		}
+	}
+	return "", errors.New("hardware: no block device serial found")
+}
+
+// physicalBlocks 返回排序后的物理块设备名
+func physicalBlocks() ([]string, error) {
+	entries, err := os.ReadDir(sysPath("sys/block"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !isVirtualBlock(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// blockDeviceSerial 读取块设备的序列号，读取不到时返回空串
+// 只读取所有用户可读的 serial 和 wwid；vpd_pg80 只有 root 可读，会使 root 和普通用户的机器码不同
+func blockDeviceSerial(name string) string {
+	device := filepath.Join("sys/block", name, "device")
+	for _, file := range []string{"serial", "wwid"} {
+		if s, err := readID(filepath.Join(device, file)); err == nil {
+			return s
+		}
+	}
+	return ""
+}
+
+func isVirtualBlock(name string) bool {
+	for _, prefix := range virtualBlockPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readID 读取单行标识文件，内容为空时返回错误
+func readID(rel string) (string, error) {
+	raw, err := os.ReadFile(sysPath(rel))
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSpace(string(raw))
+	if s == "" {
+		return "", errors.New("hardware: empty " + rel)
+	}
+	return s, nil
+}
+
+// sysPath 返回 SysRoot 下的路径
+func sysPath(rel string) string {
+	return filepath.Join(SysRoot, rel)
+}
+
+// sysNetKind 根据 /sys/class/net 判断网卡类型，目录不存在时 ok 为 false
+func sysNetKind(name string) (kind InterfaceKind, reason string, ok bool) {
+	dir := filepath.Join("sys/class/net", name)
+	if _, err := os.Stat(sysPath(dir)); err != nil {
+		return 0, "", false
+	}
+	exists := func(rel string) bool {
+		_, err := os.Stat(sysPath(filepath.Join(dir, rel)))
+		return err == nil
+	}
+	switch {
+	case exists("bridge"):
+		return KindBridge, "sysfs bridge", true
+	case exists("tun_flags"):
+		return KindTunnel, "sysfs tun/tap", true
+	case !exists("device"):
+		return KindVirtual, "no backing device", true
+	case exists("wireless"), exists("phy80211"):
+		return KindWireless, "", true
+	}
+	return KindPhysical, "", true
+}
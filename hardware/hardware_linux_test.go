@@ -0,0 +1,147 @@
+package hardware
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeRoot 在临时目录中按相对路径写入文件，并将 SysRoot 指向该目录
+func fakeRoot(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := SysRoot
+	SysRoot = root
+	t.Cleanup(func() { SysRoot = old })
+	return root
+}
+
+func TestPlatformIDs(t *testing.T) {
+	fakeRoot(t, map[string]string{
+		"etc/machine-id":                  "0123456789abcdef0123456789abcdef\n",
+		"sys/class/dmi/id/product_uuid":   "4c4c4544-0000-1010-8000-b2c04f4a3532\n",
+		"sys/block/loop0/device/serial":   "LOOP\n",
+		"sys/block/sda/device/wwid":       "t10.ATA WD-1234\n",
+		"sys/block/nvme0n1/device/serial": "  S4EWNX0N123456  \n",
+		"sys/block/dm-0/device/serial":    "DM\n",
+	})
+	uuid, disk, err := platformIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uuid != "0123456789ABCDEF0123456789ABCDEF" {
+		t.Errorf("uuid = %q", uuid)
+	}
+	if disk != "S4EWNX0N123456" {
+		t.Errorf("disk = %q, want nvme0n1 serial (sorted before sda)", disk)
+	}
+}
+
+func TestPlatformIDsFallbacks(t *testing.T) {
+	fakeRoot(t, map[string]string{
+		"sys/class/dmi/id/product_uuid": "4c4c4544-0000-1010-8000-b2c04f4a3532\n",
+		"sys/block/sda/device/vpd_pg80": "\x00\x80\x00\x08 WD-1234\x00",
+		"sys/block/sdb/device/wwid":     "t10.ATA WD-5678\n",
+	})
+	uuid, disk, _ := platformIDs()
+	if uuid != "4C4C4544-0000-1010-8000-B2C04F4A3532" {
+		t.Errorf("uuid = %q", uuid)
+	}
+	if disk != "t10.ATA WD-5678" { // vpd_pg80 只有 root 可读，不使用
+		t.Errorf("disk = %q", disk)
+	}
+}
+
+func TestSysSerialKey(t *testing.T) {
+	fakeRoot(t, map[string]string{"etc/machine-id": "aaaa\n"})
+	a, err := SysSerialKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a) != 6 {
+		t.Errorf("code %q, want 6 chars", a)
+	}
+
+	fakeRoot(t, map[string]string{"etc/machine-id": "bbbb\n"})
+	b, _ := SysSerialKey()
+	if a == b {
+		t.Errorf("different machine-id gave same code %q", a)
+	}
+	if got, want := SysGetSerialKey(), serialKey(legacyMAC(), "BBBB", ""); got != want {
+		t.Errorf("SysGetSerialKey = %q, want %q", got, want)
+	}
+}
+
+func TestSysSerialKeyNoIDs(t *testing.T) {
+	fakeRoot(t, nil)
+	if _, err := SysSerialKey(); !errors.Is(err, ErrNoHardwareID) {
+		t.Fatalf("err = %v, want ErrNoHardwareID", err)
+	}
+}
+
+func TestFingerprintComponents(t *testing.T) {
+	fakeRoot(t, map[string]string{
+		"etc/machine-id":                "0123456789abcdef\n",
+		"sys/class/dmi/id/product_uuid": "4c4c4544-0000\n",
+		"sys/block/sda/device/serial":   "WD-1234\n",
+		"proc/cpuinfo": "processor\t: 0\nvendor_id\t: GenuineIntel\ncpu family\t: 6\nmodel\t\t: 158\n" +
+			"model name\t: Intel(R) Core(TM) i7\nstepping\t: 10\nmicrocode\t: 0xf0\n\n" +
+			"processor\t: 1\nvendor_id\t: Other\n",
+	})
+	_, c, err := fingerprintComponents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c[ComponentBoard]; ok {
+		t.Error("board collected from root-only product_uuid")
+	}
+	want := map[string]string{
+		ComponentDisk: "WD-1234",
+		ComponentCPU:  "vendor_id=GenuineIntel|cpu family=6|model=158|model name=Intel(R) Core(TM) i7|stepping=10",
+		ComponentOS:   "0123456789abcdef",
+	}
+	for k, v := range want {
+		if c[k] != v {
+			t.Errorf("%s = %q, want %q", k, c[k], v)
+		}
+	}
+}
+
+func TestSysNetKind(t *testing.T) {
+	fakeRoot(t, map[string]string{
+		"sys/class/net/enp3s0/device/vendor":  "0x8086\n",
+		"sys/class/net/wlp2s0/device/vendor":  "0x8086\n",
+		"sys/class/net/wlp2s0/wireless/.keep": "",
+		"sys/class/net/lan0/bridge/stp_state": "0\n",
+		"sys/class/net/office/tun_flags":      "0x1002\n",
+		"sys/class/net/bond0/address":         "3c:97:0e:00:00:01\n",
+	})
+	tests := []struct {
+		name     string
+		kind     InterfaceKind
+		excluded bool
+	}{
+		{"enp3s0", KindPhysical, false},
+		{"wlp2s0", KindWireless, false},
+		{"lan0", KindBridge, true},
+		{"office", KindTunnel, true},
+		{"bond0", KindVirtual, true},
+	}
+	for _, tt := range tests {
+		kind, reason := classify(testIface(t, tt.name, "3c:97:0e:00:00:01", net.FlagUp))
+		if kind != tt.kind || (reason != "") != tt.excluded {
+			t.Errorf("%s: kind %v reason %q, want %v excluded=%v", tt.name, kind, reason, tt.kind, tt.excluded)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !windows && !linux
+
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-28 14:06:19
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-28 17:32:45
+ * @Description: 硬件相关（其他平台）
+ */
+
+package hardware
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// platformIDs 暂不支持的平台
+func platformIDs() (uuid, diskSerial string, err error) {
+	return "", "", fmt.Errorf("hardware: unsupported platform %s", runtime.GOOS)
+}
+
+func legacyDefaults(uuid, diskSerial string) (string, string) {
+	return uuid, diskSerial
+}
+
+func fingerprintComponents() ([]string, map[string]string, error) {
+	return hardwareMACs(), nil, fmt.Errorf("hardware: unsupported platform %s", runtime.GOOS)
+}
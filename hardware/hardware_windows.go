@@ -0,0 +1,167 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2025-12-15 11:47:25
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-28 12:24:06
+ * @Description: 硬件相关（Windows）
+ */
+
+package hardware
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+var (
+	user32               = syscall.NewLazyDLL("user32.dll")
+	procGetAsyncKeyState = user32.NewProc("GetAsyncKeyState")
+)
+
+// platformIDs 通过 wmic 读取系统 UUID 和主硬盘序列号
+// UUID 保留 wmic 原始输出（含表头），与旧版机器码保持一致
+func platformIDs() (uuid, diskSerial string, err error) {
+	var errs []error
+
+	// 注意：wmic 在较新的 Windows 版本中可能被废弃，但在旧系统中可用
+	cmd := exec.Command("wmic", "csproduct", "get", "UUID")
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // 隐藏命令窗口
+	uuidOut, e := cmd.Output()
+	if e != nil {
+		errs = append(errs, fmt.Errorf("wmic csproduct: %w", e))
+	} else {
+		uuid = string(uuidOut)
+	}
+
+	// 主硬盘 Index=0
+	cmd = exec.Command("wmic", "diskdrive", "where", "Index=0", "get", "SerialNumber")
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // 隐藏命令窗口
+	diskSerialOut, e := cmd.Output()
+	if e != nil {
+		errs = append(errs, fmt.Errorf("wmic diskdrive: %w", e))
+	} else {
+		// 清理 wmic 输出
+		diskSerial = string(diskSerialOut)
+		diskSerial = strings.Replace(diskSerial, "SerialNumber", "", 1)
+		diskSerial = strings.TrimSpace(diskSerial)
+	}
+	return uuid, diskSerial, errors.Join(errs...)
+}
+
+// legacyDefaults 旧版 SysGetSerialKey 在 wmic 失败时代入的默认值
+func legacyDefaults(uuid, diskSerial string) (string, string) {
+	if uuid == "" {
+		uuid = "BC2B8100-FD76-11EE-BE99-DA3F32D12700"
+	}
+	if diskSerial == "" {
+		diskSerial = "6479_A771_20C0_1EFF"
+	}
+	return uuid, diskSerial
+}
+
+// fingerprintComponents 读取指纹的原始组件
+func fingerprintComponents() (macs []string, components map[string]string, err error) {
+	var errs []error
+	components = map[string]string{}
+	add := func(name string, val string, e error) {
+		if e != nil {
+			errs = append(errs, e)
+			return
+		}
+		components[name] = val
+	}
+	val, e := wmicValue("csproduct", "get", "UUID")
+	add(ComponentBoard, strings.ToUpper(val), e)
+	val, e = wmicValue("diskdrive", "where", "Index=0", "get", "SerialNumber")
+	add(ComponentDisk, val, e)
+	val, e = wmicValue("cpu", "get", "ProcessorId")
+	add(ComponentCPU, val, e)
+	val, e = machineGuid()
+	add(ComponentOS, val, e)
+	return hardwareMACs(), components, errors.Join(errs...)
+}
+
+// wmicValue 执行 wmic 查询，返回表头之后的第一个值
+func wmicValue(args ...string) (string, error) {
+	cmd := exec.Command("wmic", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // 隐藏命令窗口
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("wmic %s: %w", args[0], err)
+	}
+	lines := strings.Fields(string(out))
+	if len(lines) < 2 {
+		return "", fmt.Errorf("wmic %s: empty result", args[0])
+	}
+	return strings.Join(lines[1:], " "), nil
+}
+
+// machineGuid 读取系统安装时生成的 MachineGuid
+func machineGuid() (string, error) {
+	cmd := exec.Command("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid")
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // 隐藏命令窗口
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("reg query MachineGuid: %w", err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if f := strings.Fields(line); len(f) == 3 && f[0] == "MachineGuid" {
+			return f[2], nil
+		}
+	}
+	return "", errors.New("hardware: MachineGuid not found")
+}
+
+// 定义常用键位的映射表
+var keyMap = map[string]int{
+	// 字母 (A-Z)
+	"A": 0x41, "B": 0x42, "C": 0x43, "D": 0x44, "E": 0x45,
+	"F": 0x46, "G": 0x47, "H": 0x48, "I": 0x49, "J": 0x4A,
+	"K": 0x4B, "L": 0x4C, "M": 0x4D, "N": 0x4E, "O": 0x4F,
+	"P": 0x50, "Q": 0x51, "R": 0x52, "S": 0x53, "T": 0x54,
+	"U": 0x55, "V": 0x56, "W": 0x57, "X": 0x58, "Y": 0x59, "Z": 0x5A,
+
+	// 数字 (0-9)
+	"0": 0x30, "1": 0x31, "2": 0x32, "3": 0x33, "4": 0x34,
+	"5": 0x35, "6": 0x36, "7": 0x37, "8": 0x38, "9": 0x39,
+
+	// 功能键 (F1-F12)
+	"F1": 0x70, "F2": 0x71, "F3": 0x72, "F4": 0x73, "F5": 0x74,
+	"F6": 0x75, "F7": 0x76, "F8": 0x77, "F9": 0x78, "F10": 0x79,
+	"F11": 0x7A, "F12": 0x7B,
+
+	// 特殊控制键 (区分左右)
+	"LCTRL": 0xA2, "RCTRL": 0xA3, // 左/右 Ctrl
+	"LSHIFT": 0xA0, "RSHIFT": 0xA1, // 左/右 Shift
+	"LALT": 0xA4, "RALT": 0xA5, // 左/右 Alt (Menu)
+
+	// 通用控制键 (不分左右，如果只想检测任意 Ctrl 用这个)
+	"CTRL": 0x11, "SHIFT": 0x10, "ALT": 0x12,
+
+	// 其他常用键
+	"SPACE": 0x20, "ENTER": 0x0D, "ESC": 0x1B,
+	"TAB": 0x09, "BACKSPACE": 0x08,
+	"UP": 0x26, "DOWN": 0x28, "LEFT": 0x25, "RIGHT": 0x27,
+}
+
+// 判断按键是否按下
+func KeyIsPress(keyName string) bool {
+	// 1. 将输入转为大写，防止大小写敏感问题 (比如输入 "a" 也能识别)
+	upperName := strings.ToUpper(keyName)
+
+	// 2. 从 Map 中查找对应的虚拟键码
+	vKey, ok := keyMap[upperName]
+	if !ok {
+		// 如果没找到定义的键，默认返回 false，或者你可以选择 panic
+		return false
+	}
+
+	// 3. 调用 Windows API
+	ret, _, _ := procGetAsyncKeyState.Call(uintptr(vKey))
+
+	// 4. 判断最高位 (0x8000) 是否为 1
+	return (ret & 0x8000) != 0
+}
@@ -0,0 +1,94 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-02-01 10:05:36
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-02-01 17:21:04
+ * @Description: 硬件和系统信息清单，用于排查客户问题
+ */
+
+package hardware
+
+import (
+	"os"
+	"runtime"
+	"time"
+)
+
+// InventoryReport 本机硬件和系统信息，可直接序列化为 JSON
+type InventoryReport struct {
+	Time           time.Time      `json:"time"`
+	Hostname       string         `json:"hostname"`
+	OS             OSInfo         `json:"os"`
+	CPU            CPUInfo        `json:"cpu"`
+	MemoryTotal    uint64         `json:"memory_total"` // 字节
+	Disks          []DiskInfo     `json:"disks"`
+	NICs           []MACCandidate `json:"nics"`
+	Virtualization []string       `json:"virtualization,omitempty"` // 虚拟化或容器迹象，例如 kvm、docker、wsl
+	Runtime        RuntimeInfo    `json:"runtime"`
+	Errors         []string       `json:"errors,omitempty"` // 读取失败的项目，其余信息仍然有效
+}
+
+// OSInfo 操作系统信息
+type OSInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Kernel  string `json:"kernel"`
+	Arch    string `json:"arch"`
+}
+
+// CPUInfo 处理器信息
+type CPUInfo struct {
+	Model   string `json:"model"`
+	Cores   int    `json:"cores"`   // 物理核心数
+	Threads int    `json:"threads"` // 逻辑处理器数
+}
+
+// DiskInfo 物理硬盘信息
+type DiskInfo struct {
+	Name   string `json:"name"`
+	Model  string `json:"model,omitempty"`
+	Serial string `json:"serial,omitempty"`
+	Size   uint64 `json:"size"` // 字节
+}
+
+// RuntimeInfo Go 运行时信息
+type RuntimeInfo struct {
+	GoVersion  string `json:"go_version"`
+	GOOS       string `json:"goos"`
+	GOARCH     string `json:"goarch"`
+	NumCPU     int    `json:"num_cpu"`
+	Executable string `json:"executable,omitempty"`
+}
+
+// Inventory 收集本机硬件和系统信息，单项读取失败记录在 Errors 中
+// Linux 从 SysRoot 下的 /proc、/sys 和 /etc 读取
+func Inventory() *InventoryReport {
+	r := &InventoryReport{
+		Time: time.Now(),
+		OS:   OSInfo{Arch: runtime.GOARCH},
+		Runtime: RuntimeInfo{
+			GoVersion: runtime.Version(),
+			GOOS:      runtime.GOOS,
+			GOARCH:    runtime.GOARCH,
+			NumCPU:    runtime.NumCPU(),
+		},
+		NICs: MACCandidates(),
+	}
+	if exe, err := os.Executable(); err == nil {
+		r.Runtime.Executable = exe
+	}
+	platformInventory(r)
+	if r.Hostname == "" {
+		if name, err := os.Hostname(); err == nil {
+			r.Hostname = name
+		} else {
+			r.addError("hostname", err)
+		}
+	}
+	return r
+}
+
+// addError 记录单项读取失败
+func (r *InventoryReport) addError(item string, err error) {
+	r.Errors = append(r.Errors, item+": "+err.Error())
+}
@@ -0,0 +1,230 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-02-01 10:05:36
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-02-01 17:21:04
+ * @Description: 硬件和系统信息清单（Linux），从 /proc、/sys 和 /etc 读取
+ */
+
+package hardware
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// dmiVendors DMI 厂商或产品名中的虚拟化标识
+var dmiVendors = []struct {
+	text string
+	hint string
+}{
+	{"vmware", "vmware"},
+	{"virtualbox", "virtualbox"},
+	{"kvm", "kvm"},
+	{"qemu", "qemu"},
+	{"xen", "xen"},
+	{"virtual machine", "hyper-v"},
+	{"parallels", "parallels"},
+	{"amazon ec2", "aws"},
+	{"google compute engine", "gce"},
+}
+
+// platformInventory 填充 Linux 特有的信息
+func platformInventory(r *InventoryReport) {
+	if name, err := readID("proc/sys/kernel/hostname"); err == nil {
+		r.Hostname = name
+	}
+	if err := linuxOS(&r.OS); err != nil {
+		r.addError("os", err)
+	}
+	flags, err := linuxCPU(&r.CPU)
+	if err != nil {
+		r.addError("cpu", err)
+	}
+	if total, err := memTotal(); err == nil {
+		r.MemoryTotal = total
+	} else {
+		r.addError("memory", err)
+	}
+	if disks, err := linuxDisks(); err == nil {
+		r.Disks = disks
+	} else {
+		r.addError("disks", err)
+	}
+	r.Virtualization = virtualizationHints(r.OS.Kernel, flags)
+}
+
+// linuxOS 读取发行版名称、版本和内核版本
+func linuxOS(info *OSInfo) error {
+	var errs []error
+	fields, err := keyValueFile("etc/os-release", "=")
+	if err != nil {
+		fields, err = keyValueFile("usr/lib/os-release", "=")
+	}
+	if err == nil {
+		info.Name = unquote(fields["NAME"])
+		info.Version = unquote(fields["VERSION_ID"])
+		if info.Version == "" {
+			info.Version = unquote(fields["VERSION"])
+		}
+	} else {
+		errs = append(errs, err)
+	}
+	if kernel, err := readID("proc/sys/kernel/osrelease"); err == nil {
+		info.Kernel = kernel
+	} else {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// linuxCPU 解析 /proc/cpuinfo，返回 CPU 标志位供虚拟化检测使用
+func linuxCPU(info *CPUInfo) (flags string, err error) {
+	f, err := os.Open(sysPath("proc/cpuinfo"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	cores := map[string]bool{}
+	var physical, core string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			if physical != "" || core != "" {
+				cores[physical+"/"+core] = true
+			}
+			physical, core = "", ""
+			continue
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		switch k {
+		case "processor":
+			info.Threads++
+		case "model name", "Hardware":
+			if info.Model == "" {
+				info.Model = v
+			}
+		case "physical id":
+			physical = v
+		case "core id":
+			core = v
+		case "flags":
+			if flags == "" {
+				flags = v
+			}
+		}
+	}
+	if physical != "" || core != "" {
+		cores[physical+"/"+core] = true
+	}
+	info.Cores = len(cores)
+	if info.Cores == 0 {
+		info.Cores = info.Threads
+	}
+	return flags, sc.Err()
+}
+
+// memTotal 读取 /proc/meminfo 中的 MemTotal
+func memTotal() (uint64, error) {
+	fields, err := keyValueFile("proc/meminfo", ":")
+	if err != nil {
+		return 0, err
+	}
+	kb, err := strconv.ParseUint(strings.TrimSuffix(fields["MemTotal"], " kB"), 10, 64)
+	if err != nil {
+		return 0, errors.New("hardware: MemTotal not found in meminfo")
+	}
+	return kb * 1024, nil
+}
+
+// linuxDisks 列出物理块设备，size 文件以 512 字节扇区为单位
+func linuxDisks() ([]DiskInfo, error) {
+	names, err := physicalBlocks()
+	if err != nil {
+		return nil, err
+	}
+	disks := make([]DiskInfo, 0, len(names))
+	for _, name := range names {
+		d := DiskInfo{Name: name, Serial: blockDeviceSerial(name)}
+		if model, err := readID(filepath.Join("sys/block", name, "device/model")); err == nil {
+			d.Model = model
+		}
+		if sectors, err := readID(filepath.Join("sys/block", name, "size")); err == nil {
+			if n, err := strconv.ParseUint(sectors, 10, 64); err == nil {
+				d.Size = n * 512
+			}
+		}
+		disks = append(disks, d)
+	}
+	return disks, nil
+}
+
+// virtualizationHints 汇总 DMI、CPU 标志、容器和 WSL 的迹象
+func virtualizationHints(kernel, cpuFlags string) []string {
+	var hints []string
+	seen := map[string]bool{}
+	add := func(h string) {
+		if !seen[h] {
+			seen[h] = true
+			hints = append(hints, h)
+		}
+	}
+
+	for _, file := range []string{"sys_vendor", "product_name"} {
+		val, err := readID(filepath.Join("sys/class/dmi/id", file))
+		if err != nil {
+			continue
+		}
+		val = strings.ToLower(val)
+		for _, v := range dmiVendors {
+			if strings.Contains(val, v.text) {
+				add(v.hint)
+			}
+		}
+	}
+	if strings.Contains(" "+cpuFlags+" ", " hypervisor ") {
+		add("hypervisor")
+	}
+	if _, err := os.Stat(sysPath(".dockerenv")); err == nil {
+		add("docker")
+	}
+	if cgroup, err := os.ReadFile(sysPath("proc/1/cgroup")); err == nil {
+		for _, name := range []string{"docker", "kubepods", "lxc", "containerd"} {
+			if strings.Contains(string(cgroup), name) {
+				add(name)
+			}
+		}
+	}
+	if strings.Contains(strings.ToLower(kernel), "microsoft") {
+		add("wsl")
+	}
+	return hints
+}
+
+// keyValueFile 读取 key<sep>value 形式的文件，值去掉首尾空白
+func keyValueFile(rel, sep string) (map[string]string, error) {
+	raw, err := os.ReadFile(sysPath(rel))
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]string{}
+	for _, line := range strings.Split(string(raw), "\n") {
+		if k, v, ok := strings.Cut(line, sep); ok {
+			fields[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+	}
+	return fields, nil
+}
+
+func unquote(s string) string {
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return strings.Trim(s, `"'`)
+}
@@ -0,0 +1,83 @@
+package hardware
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestInventory(t *testing.T) {
+	cpu := ""
+	for _, id := range []string{"0", "1", "2", "3"} {
+		core := map[string]string{"0": "0", "1": "0", "2": "1", "3": "1"}[id]
+		cpu += "processor\t: " + id + "\nvendor_id\t: GenuineIntel\nmodel name\t: Intel(R) Xeon(R) CPU\n" +
+			"physical id\t: 0\ncore id\t\t: " + core + "\nflags\t\t: fpu vme hypervisor sse\n\n"
+	}
+	fakeRoot(t, map[string]string{
+		"proc/sys/kernel/hostname":       "build-01\n",
+		"proc/sys/kernel/osrelease":      "5.15.0-91-generic\n",
+		"etc/os-release":                 "NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nID=ubuntu\n",
+		"proc/cpuinfo":                   cpu,
+		"proc/meminfo":                   "MemTotal:       16314680 kB\nMemFree:         1024 kB\n",
+		"proc/1/cgroup":                  "0::/system.slice/docker-abc.scope\n",
+		"sys/class/dmi/id/sys_vendor":    "QEMU\n",
+		"sys/class/dmi/id/product_name":  "Standard PC (Q35 + ICH9, 2009)\n",
+		"sys/block/vda/size":             "41943040\n",
+		"sys/block/vda/device/serial":    "VDISK01\n",
+		"sys/block/nvme0n1/size":         "1000215216\n",
+		"sys/block/nvme0n1/device/model": "Samsung SSD 980\n",
+		"sys/block/loop0/size":           "8\n",
+	})
+	r := Inventory()
+
+	if r.Hostname != "build-01" {
+		t.Errorf("Hostname = %q", r.Hostname)
+	}
+	if want := (OSInfo{Name: "Ubuntu", Version: "22.04", Kernel: "5.15.0-91-generic", Arch: r.Runtime.GOARCH}); r.OS != want {
+		t.Errorf("OS = %+v, want %+v", r.OS, want)
+	}
+	if want := (CPUInfo{Model: "Intel(R) Xeon(R) CPU", Cores: 2, Threads: 4}); r.CPU != want {
+		t.Errorf("CPU = %+v, want %+v", r.CPU, want)
+	}
+	if r.MemoryTotal != 16314680*1024 {
+		t.Errorf("MemoryTotal = %d", r.MemoryTotal)
+	}
+	wantDisks := []DiskInfo{
+		{Name: "nvme0n1", Model: "Samsung SSD 980", Size: 1000215216 * 512},
+		{Name: "vda", Serial: "VDISK01", Size: 41943040 * 512},
+	}
+	if !reflect.DeepEqual(r.Disks, wantDisks) {
+		t.Errorf("Disks = %+v, want %+v", r.Disks, wantDisks)
+	}
+	if want := []string{"qemu", "hypervisor", "docker"}; !reflect.DeepEqual(r.Virtualization, want) {
+		t.Errorf("Virtualization = %v, want %v", r.Virtualization, want)
+	}
+	if len(r.Errors) != 0 {
+		t.Errorf("Errors = %v", r.Errors)
+	}
+	raw, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back InventoryReport
+	if err := json.Unmarshal(raw, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back.NICs, r.NICs) {
+		t.Errorf("NICs round trip = %+v, want %+v", back.NICs, r.NICs)
+	}
+}
+
+func TestInventoryPartial(t *testing.T) {
+	fakeRoot(t, map[string]string{"proc/meminfo": "MemTotal: 2048 kB\n"})
+	r := Inventory()
+	if r.MemoryTotal != 2048*1024 {
+		t.Errorf("MemoryTotal = %d", r.MemoryTotal)
+	}
+	if len(r.Errors) == 0 {
+		t.Error("missing /proc/cpuinfo and /sys/block not reported")
+	}
+	if r.Hostname == "" || r.Runtime.GoVersion == "" {
+		t.Errorf("fallback fields empty: %+v", r)
+	}
+}
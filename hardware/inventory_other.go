@@ -0,0 +1,21 @@
+//go:build !windows && !linux
+
+/*
+ * @Author: 2Kil
+ * @Date: 2026-02-01 10:05:36
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-02-01 17:21:04
+ * @Description: 硬件和系统信息清单（其他平台）
+ */
+
+package hardware
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// platformInventory 暂不支持的平台只返回通用信息
+func platformInventory(r *InventoryReport) {
+	r.addError("platform", fmt.Errorf("unsupported platform %s", runtime.GOOS))
+}
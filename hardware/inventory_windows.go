@@ -0,0 +1,97 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-02-01 10:05:36
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-02-01 17:21:04
+ * @Description: 硬件和系统信息清单（Windows），通过 wmic 读取
+ */
+
+package hardware
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// platformInventory 填充 Windows 特有的信息
+func platformInventory(r *InventoryReport) {
+	if rows, err := wmicList("os", "Caption", "Version", "BuildNumber"); err == nil && len(rows) > 0 {
+		r.OS.Name = rows[0]["Caption"]
+		r.OS.Version = rows[0]["Version"]
+		r.OS.Kernel = rows[0]["BuildNumber"]
+	} else if err != nil {
+		r.addError("os", err)
+	}
+
+	if rows, err := wmicList("cpu", "Name", "NumberOfCores", "NumberOfLogicalProcessors"); err == nil {
+		for i, row := range rows {
+			if i == 0 {
+				r.CPU.Model = row["Name"]
+			}
+			cores, _ := strconv.Atoi(row["NumberOfCores"])
+			threads, _ := strconv.Atoi(row["NumberOfLogicalProcessors"])
+			r.CPU.Cores += cores
+			r.CPU.Threads += threads
+		}
+	} else {
+		r.addError("cpu", err)
+	}
+
+	if rows, err := wmicList("computersystem", "TotalPhysicalMemory", "Manufacturer", "Model"); err == nil && len(rows) > 0 {
+		r.MemoryTotal, _ = strconv.ParseUint(rows[0]["TotalPhysicalMemory"], 10, 64)
+		vendor := strings.ToLower(rows[0]["Manufacturer"] + " " + rows[0]["Model"])
+		for _, hint := range []string{"vmware", "virtualbox", "kvm", "qemu", "xen", "parallels"} {
+			if strings.Contains(vendor, hint) {
+				r.Virtualization = append(r.Virtualization, hint)
+			}
+		}
+		if strings.Contains(vendor, "virtual machine") {
+			r.Virtualization = append(r.Virtualization, "hyper-v")
+		}
+	} else if err != nil {
+		r.addError("memory", err)
+	}
+
+	if rows, err := wmicList("diskdrive", "Index", "Model", "SerialNumber", "Size"); err == nil {
+		for _, row := range rows {
+			size, _ := strconv.ParseUint(row["Size"], 10, 64)
+			r.Disks = append(r.Disks, DiskInfo{
+				Name:   "disk" + row["Index"],
+				Model:  row["Model"],
+				Serial: row["SerialNumber"],
+				Size:   size,
+			})
+		}
+	} else {
+		r.addError("disks", err)
+	}
+}
+
+// wmicList 以 /value 格式查询 wmic，每个实例返回一组 属性=值
+func wmicList(class string, props ...string) ([]map[string]string, error) {
+	cmd := exec.Command("wmic", class, "get", strings.Join(props, ","), "/value")
+	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true} // 隐藏命令窗口
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("wmic %s: %w", class, err)
+	}
+	var rows []map[string]string
+	var row map[string]string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			row = nil // 空行分隔不同实例
+			continue
+		}
+		if row == nil {
+			row = map[string]string{}
+			rows = append(rows, row)
+		}
+		row[k] = strings.TrimSpace(v)
+	}
+	return rows, nil
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-28 14:06:19
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-28 17:32:45
+ * @Description: 按键检测（非 Windows）
+ */
+
+package hardware
+
+// KeyIsPress 非 Windows 平台不支持全局按键检测，始终返回 false
+func KeyIsPress(keyName string) bool {
+	return false
+}
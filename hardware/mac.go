@@ -0,0 +1,229 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-30 10:12:44
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-30 17:05:29
+ * @Description: 网卡分类和稳定的 MAC 选择
+ */
+
+package hardware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// InterfaceKind 网卡类型
+type InterfaceKind int
+
+const (
+	KindPhysical InterfaceKind = iota // 物理有线网卡
+	KindWireless                      // 物理无线网卡
+	KindVM                            // 虚拟机中的网卡，没有物理网卡时才使用
+	KindVirtual                       // 其他虚拟网卡
+	KindBridge                        // 网桥
+	KindTunnel                        // VPN 隧道
+	KindVeth                          // 容器 veth 对
+	KindLoopback                      // 回环
+)
+
+// String 返回网卡类型名称
+func (k InterfaceKind) String() string {
+	switch k {
+	case KindPhysical:
+		return "physical"
+	case KindWireless:
+		return "wireless"
+	case KindVM:
+		return "vm"
+	case KindVirtual:
+		return "virtual"
+	case KindBridge:
+		return "bridge"
+	case KindTunnel:
+		return "tunnel"
+	case KindVeth:
+		return "veth"
+	case KindLoopback:
+		return "loopback"
+	}
+	return "unknown"
+}
+
+// MarshalText 以名称序列化，便于阅读 JSON 报告
+func (k InterfaceKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText 解析 MarshalText 的输出
+func (k *InterfaceKind) UnmarshalText(text []byte) error {
+	for kind := KindPhysical; kind <= KindLoopback; kind++ {
+		if kind.String() == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("hardware: unknown interface kind %q", text)
+}
+
+// ErrNoMAC 没有可用于机器码的网卡
+var ErrNoMAC = errors.New("hardware: no usable network interface")
+
+// MACCandidate 一块网卡的分类结果
+type MACCandidate struct {
+	Name     string        `json:"name"`
+	MAC      string        `json:"mac,omitempty"` // 小写冒号分隔
+	Up       bool          `json:"up"`            // 只用于展示，不影响选择
+	Kind     InterfaceKind `json:"kind"`
+	Excluded string        `json:"excluded,omitempty"` // 排除原因，为空表示参与选择
+}
+
+// interfacePatterns 按名称识别虚拟网卡，前缀匹配，忽略大小写
+var interfacePatterns = []struct {
+	prefix string
+	kind   InterfaceKind
+}{
+	{"vethernet", KindBridge}, // Hyper-V 虚拟交换机，需在 veth 之前
+	{"veth", KindVeth},
+	{"docker", KindBridge},
+	{"br-", KindBridge},
+	{"virbr", KindBridge},
+	{"cni", KindBridge},
+	{"lxcbr", KindBridge},
+	{"tun", KindTunnel},
+	{"tap", KindTunnel},
+	{"utun", KindTunnel},
+	{"wg", KindTunnel},
+	{"ppp", KindTunnel},
+	{"zt", KindTunnel},
+	{"tailscale", KindTunnel},
+	{"wireguard", KindTunnel},
+	{"openvpn", KindTunnel},
+	{"vmnet", KindVirtual},
+	{"vboxnet", KindVirtual},
+	{"virtualbox", KindVirtual},
+	{"vmware", KindVirtual},
+	{"hyper-v", KindVirtual},
+	{"npcap", KindVirtual},
+	{"bluetooth", KindVirtual},
+}
+
+// containsPatterns Windows 网卡名称中间出现的虚拟网卡标识
+var containsPatterns = []struct {
+	text string
+	kind InterfaceKind
+}{
+	{"tap-windows", KindTunnel},
+	{"virtual", KindVirtual},
+	{"loopback", KindLoopback},
+}
+
+// vmOUIs 虚拟化平台分配给虚拟机网卡的 OUI
+var vmOUIs = map[string]string{
+	"00:05:69": "VMware",
+	"00:0c:29": "VMware",
+	"00:1c:14": "VMware",
+	"00:50:56": "VMware",
+	"08:00:27": "VirtualBox",
+	"0a:00:27": "VirtualBox",
+	"00:15:5d": "Hyper-V",
+	"00:16:3e": "Xen",
+	"52:54:00": "QEMU/KVM",
+	"00:1c:42": "Parallels",
+}
+
+// wirelessPrefixes 无线网卡的名称前缀
+var wirelessPrefixes = []string{"wl", "wi-fi", "wifi", "wireless", "wlan", "无线"}
+
+// MACCandidates 返回本机所有网卡的分类结果，按选择优先级稳定排序
+// 顺序：物理有线、物理无线、虚拟机网卡，同类按 MAC 排序；被排除的网卡排在最后
+func MACCandidates() []MACCandidate {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	return classifyInterfaces(interfaces)
+}
+
+// PrimaryMAC 返回优先级最高的网卡 MAC，不受网卡启用状态和系统枚举顺序影响
+func PrimaryMAC() (string, error) {
+	for _, c := range MACCandidates() {
+		if c.Excluded == "" {
+			return c.MAC, nil
+		}
+	}
+	return "", ErrNoMAC
+}
+
+// classifyInterfaces 分类并排序
+func classifyInterfaces(interfaces []net.Interface) []MACCandidate {
+	list := make([]MACCandidate, 0, len(interfaces))
+	for _, iface := range interfaces {
+		c := MACCandidate{Name: iface.Name, MAC: iface.HardwareAddr.String(), Up: iface.Flags&net.FlagUp != 0}
+		c.Kind, c.Excluded = classify(iface)
+		list = append(list, c)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if (a.Excluded == "") != (b.Excluded == "") {
+			return a.Excluded == ""
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.MAC != b.MAC {
+			return a.MAC < b.MAC
+		}
+		return a.Name < b.Name
+	})
+	return list
+}
+
+// classify 判断网卡类型和排除原因
+func classify(iface net.Interface) (InterfaceKind, string) {
+	if iface.Flags&net.FlagLoopback != 0 {
+		return KindLoopback, "loopback"
+	}
+	if len(iface.HardwareAddr) != 6 {
+		return KindVirtual, "no ethernet hardware address"
+	}
+	name := strings.ToLower(iface.Name)
+	for _, p := range interfacePatterns {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.kind, "name matches " + p.prefix + "*"
+		}
+	}
+	for _, p := range containsPatterns {
+		if strings.Contains(name, p.text) {
+			return p.kind, "name contains " + p.text
+		}
+	}
+	wireless := false
+	if kind, reason, ok := sysNetKind(iface.Name); ok {
+		if reason != "" {
+			return kind, reason
+		}
+		wireless = kind == KindWireless
+	}
+
+	mac := iface.HardwareAddr.String()
+	if _, ok := vmOUIs[mac[:8]]; ok {
+		return KindVM, ""
+	}
+	if iface.HardwareAddr[0]&0x02 != 0 {
+		return KindVirtual, "locally administered address"
+	}
+	if iface.HardwareAddr[0]&0x01 != 0 {
+		return KindVirtual, "multicast address"
+	}
+	for _, p := range wirelessPrefixes {
+		wireless = wireless || strings.HasPrefix(name, p)
+	}
+	if wireless {
+		return KindWireless, ""
+	}
+	return KindPhysical, ""
+}
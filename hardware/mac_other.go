@@ -0,0 +1,16 @@
+//go:build !linux
+
+/*
+ * @Author: 2Kil
+ * @Date: 2026-01-30 10:12:44
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-01-30 17:05:29
+ * @Description: 网卡分类（非 Linux）
+ */
+
+package hardware
+
+// sysNetKind 非 Linux 平台只按名称和 OUI 判断
+func sysNetKind(name string) (InterfaceKind, string, bool) {
+	return 0, "", false
+}
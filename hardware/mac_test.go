@@ -0,0 +1,100 @@
+package hardware
+
+import (
+	"net"
+	"testing"
+)
+
+func testIface(t *testing.T, name, mac string, flags net.Flags) net.Interface {
+	t.Helper()
+	var hw net.HardwareAddr
+	if mac != "" {
+		var err error
+		if hw, err = net.ParseMAC(mac); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return net.Interface{Name: name, HardwareAddr: hw, Flags: flags}
+}
+
+func TestClassifyInterfaces(t *testing.T) {
+	old := SysRoot
+	SysRoot = t.TempDir() // 不读取真实的 /sys
+	t.Cleanup(func() { SysRoot = old })
+
+	ifaces := []net.Interface{
+		testIface(t, "docker0", "02:42:ac:11:00:01", net.FlagUp),
+		testIface(t, "lo", "", net.FlagUp|net.FlagLoopback),
+		testIface(t, "wlan0", "a4:5e:60:00:00:02", 0),
+		testIface(t, "tun0", "", net.FlagUp),
+		testIface(t, "vEthernet (Default Switch)", "00:15:5d:00:00:03", net.FlagUp),
+		testIface(t, "eth1", "3c:97:0e:00:00:09", 0),
+		testIface(t, "eth0", "3c:97:0e:00:00:01", net.FlagUp),
+		testIface(t, "ens3", "52:54:00:12:34:56", net.FlagUp),
+		testIface(t, "Ethernet 2", "06:00:00:00:00:01", net.FlagUp),
+		testIface(t, "veth1a2b", "9a:00:00:00:00:01", net.FlagUp),
+	}
+	got := classifyInterfaces(ifaces)
+
+	want := []struct {
+		name     string
+		kind     InterfaceKind
+		excluded bool
+	}{
+		{"eth0", KindPhysical, false},
+		{"eth1", KindPhysical, false}, // 未启用也参与选择
+		{"wlan0", KindWireless, false},
+		{"ens3", KindVM, false},
+	}
+	for i, w := range want {
+		if got[i].Name != w.name || got[i].Kind != w.kind || (got[i].Excluded != "") != w.excluded {
+			t.Errorf("candidate %d = %+v, want %s %v", i, got[i], w.name, w.kind)
+		}
+	}
+	excluded := map[string]InterfaceKind{
+		"docker0":                    KindBridge,
+		"lo":                         KindLoopback,
+		"tun0":                       KindVirtual, // 没有硬件地址
+		"vEthernet (Default Switch)": KindBridge,
+		"Ethernet 2":                 KindVirtual,
+		"veth1a2b":                   KindVeth,
+	}
+	for _, c := range got[len(want):] {
+		kind, ok := excluded[c.Name]
+		if !ok || c.Excluded == "" || c.Kind != kind {
+			t.Errorf("%s: kind %v excluded %q, want %v excluded", c.Name, c.Kind, c.Excluded, kind)
+		}
+	}
+
+	// 枚举顺序变化不影响结果
+	reversed := make([]net.Interface, len(ifaces))
+	for i := range ifaces {
+		reversed[len(ifaces)-1-i] = ifaces[i]
+	}
+	again := classifyInterfaces(reversed)
+	for i := range got {
+		if got[i] != again[i] {
+			t.Fatalf("order depends on enumeration: %v vs %v", got, again)
+		}
+	}
+}
+
+// SysGetSerialKey 的 MAC 选择必须保持旧版行为：按枚举顺序取第一个已启用的非回环网卡，虚拟网卡也不跳过
+func TestLegacyMACSelection(t *testing.T) {
+	ifaces := []net.Interface{
+		testIface(t, "lo", "", net.FlagUp|net.FlagLoopback),
+		testIface(t, "Ethernet", "3c:97:0e:00:00:01", 0),
+		testIface(t, "vEthernet (Default Switch)", "00:15:5d:00:00:03", net.FlagUp),
+		testIface(t, "Wi-Fi", "a4:5e:60:00:00:02", net.FlagUp),
+	}
+	if got := firstUpMAC(ifaces); got != "00:15:5d:00:00:03" {
+		t.Errorf("firstUpMAC = %q, want the first up interface", got)
+	}
+	// 旧版算法的固定结果，算法或拼接顺序变化会使已发放的机器码失效
+	if got := serialKey(firstUpMAC(ifaces), "BC2B8100-FD76-11EE-BE99-DA3F32D12700", "6479_A771_20C0_1EFF"); got != "FF5E3D" {
+		t.Errorf("serialKey = %q, want FF5E3D", got)
+	}
+	if firstUpMAC(ifaces[:2]) != "" {
+		t.Error("down interface selected")
+	}
+}
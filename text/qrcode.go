@@ -0,0 +1,425 @@
+/*
+ * @Author: 2Kil
+ * @Date: 2026-02-03 10:06:21
+ * @LastEditors: 2Kil
+ * @LastEditTime: 2026-02-03 16:42:37
+ * @Description:二维码生成，字节模式、纠错等级 M、版本 1-10，用于在终端显示激活请求等短文本
+ */
+
+package text
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrQRCodeTooLong 文本超过版本 10 的容量（213 字节）
+var ErrQRCodeTooLong = errors.New("text: too long for qr code")
+
+// qrBlocks 纠错等级 M 下各版本的码字总数、纠错块数和每块纠错码字数
+var qrBlocks = [...]struct{ total, blocks, ecc int }{
+	1: {26, 1, 10}, 2: {44, 1, 16}, 3: {70, 1, 26}, 4: {100, 2, 18}, 5: {134, 2, 24},
+	6: {172, 4, 16}, 7: {196, 4, 18}, 8: {242, 4, 22}, 9: {292, 5, 22}, 10: {346, 5, 26},
+}
+
+// qrAlign 各版本校正图形的中心坐标
+var qrAlign = [...][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+}
+
+// qrCode 生成过程中的模块矩阵，fn 标记定位、时序等功能图形
+type qrCode struct {
+	size    int
+	modules [][]bool
+	fn      [][]bool
+}
+
+// TextQRCode 将文本编码为二维码矩阵，true 为深色模块，不含四周留白
+// 自动选择能容纳文本的最小版本和罚分最低的掩码
+func TextQRCode(s string) ([][]bool, error) {
+	data := []byte(s)
+	version := 0
+	for v := 1; v < len(qrBlocks); v++ {
+		if len(data) <= qrDataLen(v)-qrHeaderLen(v) {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrQRCodeTooLong
+	}
+
+	q := newQRCode(version)
+	q.drawFunctions(version)
+	q.drawCodewords(qrCodewords(version, data))
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		q.applyMask(mask) // 异或两次恢复原状
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+	return q.modules, nil
+}
+
+// TextQRCodeTerminal 用上下半块字符把二维码渲染为终端文本，每行字符对应两行模块，带标准要求的 4 个模块留白
+// 按深色背景的终端渲染：浅色模块和留白用块字符画出，深色模块为空格
+func TextQRCodeTerminal(s string) (string, error) {
+	modules, err := TextQRCode(s)
+	if err != nil {
+		return "", err
+	}
+	const quiet = 4
+	size := len(modules)
+	dark := func(x, y int) bool {
+		x, y = x-quiet, y-quiet
+		return x >= 0 && y >= 0 && x < size && y < size && modules[y][x]
+	}
+	var b strings.Builder
+	for y := 0; y < size+2*quiet; y += 2 {
+		for x := 0; x < size+2*quiet; x++ {
+			top, bottom := !dark(x, y), !dark(x, y+1) // 需要画出的浅色部分
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// qrDataLen 数据码字数
+func qrDataLen(version int) int {
+	b := qrBlocks[version]
+	return b.total - b.blocks*b.ecc
+}
+
+// qrHeaderLen 字节模式的模式指示符和长度字段占用的字节数（向上取整）
+func qrHeaderLen(version int) int {
+	if version < 10 {
+		return 2 // 4 + 8 位
+	}
+	return 3 // 4 + 16 位
+}
+
+// qrCodewords 编码数据、补齐并按块计算纠错码后交织
+func qrCodewords(version int, data []byte) []byte {
+	var bits []bool
+	put := func(val, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, val>>i&1 == 1)
+		}
+	}
+	put(0b0100, 4)
+	if version < 10 {
+		put(len(data), 8)
+	} else {
+		put(len(data), 16)
+	}
+	for _, c := range data {
+		put(int(c), 8)
+	}
+	capacity := qrDataLen(version) * 8
+	put(0, min(4, capacity-len(bits)))
+	put(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		put(pad, 8)
+	}
+	codewords := make([]byte, len(bits)/8)
+	for i, bit := range bits {
+		if bit {
+			codewords[i/8] |= 1 << (7 - i%8)
+		}
+	}
+
+	// 前面的块比后面的块少一个数据码字
+	b := qrBlocks[version]
+	short := len(codewords) / b.blocks
+	shortBlocks := b.blocks - len(codewords)%b.blocks
+	divisor := rsDivisor(b.ecc)
+	var dataBlocks, eccBlocks [][]byte
+	for i, off := 0, 0; i < b.blocks; i++ {
+		n := short
+		if i >= shortBlocks {
+			n++
+		}
+		block := codewords[off : off+n]
+		off += n
+		dataBlocks = append(dataBlocks, block)
+		eccBlocks = append(eccBlocks, rsRemainder(block, divisor))
+	}
+	out := make([]byte, 0, b.total)
+	for i := 0; i <= short; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				out = append(out, block[i])
+			}
+		}
+	}
+	for i := 0; i < b.ecc; i++ {
+		for _, block := range eccBlocks {
+			out = append(out, block[i])
+		}
+	}
+	return out
+}
+
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	q := &qrCode{size: size, modules: make([][]bool, size), fn: make([][]bool, size)}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.fn[i] = make([]bool, size)
+	}
+	return q
+}
+
+// set 设置功能图形模块，x 为列，y 为行
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.fn[y][x] = true
+}
+
+// drawFunctions 绘制时序、定位、校正图形，并为格式和版本信息占位
+func (q *qrCode) drawFunctions(version int) {
+	for i := 0; i < q.size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	for _, c := range [][2]int{{3, 3}, {q.size - 4, 3}, {3, q.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := c[0]+dx, c[1]+dy
+				if x >= 0 && y >= 0 && x < q.size && y < q.size {
+					d := max(abs(dx), abs(dy))
+					q.set(x, y, d != 2 && d != 4)
+				}
+			}
+		}
+	}
+	pos := qrAlign[version]
+	for i, x := range pos {
+		for j, y := range pos {
+			last := len(pos) - 1
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue // 与定位图形重叠
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	q.drawFormat(0)
+	if version >= 7 {
+		bits := version<<12 | bchRemainder(version, 12, 0x1F25)
+		for i := 0; i < 18; i++ {
+			a, b := q.size-11+i%3, i/3
+			q.set(a, b, bits>>i&1 == 1)
+			q.set(b, a, bits>>i&1 == 1)
+		}
+	}
+}
+
+// drawFormat 写入纠错等级 M 和掩码编号的格式信息，两处各一份
+func (q *qrCode) drawFormat(mask int) {
+	bits := qrFormatBits(mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true) // 固定的深色模块
+}
+
+// qrFormatBits 纠错等级 M 的指示位为 00
+func qrFormatBits(mask int) int {
+	return (mask<<10 | bchRemainder(mask, 10, 0x537)) ^ 0x5412
+}
+
+// bchRemainder 计算 BCH 校验位
+func bchRemainder(data, n, poly int) int {
+	rem := data
+	for i := 0; i < n; i++ {
+		rem = rem<<1 ^ (rem>>(n-1))*poly
+	}
+	return rem
+}
+
+// drawCodewords 从右下角起每两列一组蛇形填充数据，跳过功能图形和第 6 列
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < q.size; vert++ {
+			y := vert
+			if upward {
+				y = q.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if !q.fn[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask 对数据区域按掩码取反，再次调用可撤销
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.fn[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty 按标准的四条规则计算罚分，用于选择掩码
+func (q *qrCode) penalty() int {
+	n := q.size
+	score := 0
+	line := func(get func(i int) bool) {
+		run := 1
+		for i := 1; i <= n; i++ {
+			if i < n && get(i) == get(i-1) {
+				run++
+				continue
+			}
+			if run >= 5 {
+				score += run - 2
+			}
+			run = 1
+		}
+		// 1:1:3:1:1 的类定位图形，一侧带 4 个浅色模块
+		for i := 0; i+7 <= n; i++ {
+			if get(i) && !get(i+1) && get(i+2) && get(i+3) && get(i+4) && !get(i+5) && get(i+6) {
+				before, after := true, true
+				for k := 1; k <= 4; k++ {
+					before = before && (i-k < 0 || !get(i-k))
+					after = after && (i+6+k >= n || !get(i+6+k))
+				}
+				if before || after {
+					score += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < n; y++ {
+		line(func(i int) bool { return q.modules[y][i] })
+		line(func(i int) bool { return q.modules[i][y] })
+		for x := 0; x < n; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := q.modules[y][x]
+				if c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+					score += 3
+				}
+			}
+		}
+	}
+	total := n * n
+	k := (abs(dark*20-total*10) + total - 1) / total
+	return score + max(k-1, 0)*10
+}
+
+// rsMul GF(2^8) 乘法，本原多项式 0x11D
+func rsMul(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor 生成多项式 (x-α^0)(x-α^1)…(x-α^(degree-1))，省略最高次项系数 1
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = rsMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = rsMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder 计算数据多项式除以生成多项式的余式，即纠错码字
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= rsMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,265 @@
+package text
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQRCodeReedSolomon(t *testing.T) {
+	// 标准示例 HELLO WORLD（1-M，字母数字模式）的数据码字和纠错码字
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, rsDivisor(10)); !bytes.Equal(got, want) {
+		t.Fatalf("ecc = %v, want %v", got, want)
+	}
+	if got := qrFormatBits(0); got != 0b101010000010010 {
+		t.Fatalf("format bits M/0 = %015b", got)
+	}
+	if got := 7<<12 | bchRemainder(7, 12, 0x1F25); got != 0x07C94 {
+		t.Fatalf("version 7 bits = %05x", got)
+	}
+}
+
+// readQRCode 按生成的逆过程读回数据：格式信息、去掩码、蛇形读取、解交织
+func readQRCode(t *testing.T, modules [][]bool) string {
+	t.Helper()
+	size := len(modules)
+	version := (size - 17) / 4
+	format := 0
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | b2i(modules[8][14-i])
+	}
+	format = format<<1 | b2i(modules[8][7])
+	format = format<<1 | b2i(modules[8][8])
+	format = format<<1 | b2i(modules[7][8])
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | b2i(modules[i][8])
+	}
+	format ^= 0x5412
+	mask := format >> 10 & 7
+	if format>>13 != 0 || qrFormatBits(mask) != format^0x5412 {
+		t.Fatalf("format bits %015b", format)
+	}
+
+	q := newQRCode(version)
+	q.drawFunctions(version)
+	for y := range modules {
+		copy(q.modules[y], modules[y])
+	}
+	q.applyMask(mask)
+	var raw []byte
+	n := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			y := vert
+			if (right+1)&2 == 0 {
+				y = size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !q.fn[y][x] {
+					if n%8 == 0 {
+						raw = append(raw, 0)
+					}
+					raw[n/8] |= byte(b2i(q.modules[y][x])) << (7 - n%8)
+					n++
+				}
+			}
+		}
+	}
+
+	b := qrBlocks[version]
+	dataLen := qrDataLen(version)
+	short := dataLen / b.blocks
+	shortBlocks := b.blocks - dataLen%b.blocks
+	blocks := make([][]byte, b.blocks)
+	off := 0
+	for i := 0; i <= short; i++ {
+		for j := range blocks {
+			if i < short || j >= shortBlocks {
+				blocks[j] = append(blocks[j], raw[off])
+				off++
+			}
+		}
+	}
+	for j, block := range blocks {
+		ecc := make([]byte, b.ecc)
+		for i := range ecc {
+			ecc[i] = raw[dataLen+i*b.blocks+j]
+		}
+		if !bytes.Equal(rsRemainder(block, rsDivisor(b.ecc)), ecc) {
+			t.Fatalf("block %d ecc mismatch", j)
+		}
+	}
+	codewords := bytes.Join(blocks, nil)
+
+	if codewords[0]>>4 != 0b0100 {
+		t.Fatalf("mode = %04b", codewords[0]>>4)
+	}
+	bits := codewords
+	length, start := int(bits[0]&0x0F)<<4|int(bits[1]>>4), 1
+	if version >= 10 {
+		length, start = int(bits[0]&0x0F)<<12|int(bits[1])<<4|int(bits[2]>>4), 2
+	}
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = bits[start+i]<<4 | bits[start+i+1]>>4
+	}
+	return string(out)
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestQRCodeRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"tkstar",
+		strings.Repeat("A", 14),    // 版本 1 的最大容量
+		strings.Repeat("B", 15),    // 版本 2
+		strings.Repeat("x1-_", 30), // 版本 7，带版本信息
+		"tka1.eyJtYyI6IjFLN1FYLU0zVjlBLTJQRFIiLCJwcmQiOiJkZW1vIiwidmVyIjoiMS4yLjMiLCJ0IjoiMjAyNi0wMS0yNFQwODowMDowMFoifQ.Qm9ndXNNQUNmb3JUZXN0cw",
+		strings.Repeat("z", 213), // 版本 10 的最大容量
+	}
+	for _, in := range inputs {
+		modules, err := TextQRCode(in)
+		if err != nil {
+			t.Fatalf("TextQRCode(%d bytes): %v", len(in), err)
+		}
+		if got := readQRCode(t, modules); got != in {
+			t.Fatalf("round trip %d bytes = %q", len(in), got)
+		}
+	}
+	if size := len(mustQR(t, strings.Repeat("A", 14))); size != 21 {
+		t.Fatalf("14 bytes size = %d, want version 1", size)
+	}
+	if size := len(mustQR(t, strings.Repeat("A", 15))); size != 25 {
+		t.Fatalf("15 bytes size = %d, want version 2", size)
+	}
+	if _, err := TextQRCode(strings.Repeat("z", 214)); !errors.Is(err, ErrQRCodeTooLong) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// qrGolden 由独立实现 github.com/skip2/go-qrcode（Medium 纠错、无留白）生成，# 为深色模块
+// 两者选出的掩码一致，可直接与 TextQRCode 的输出比较
+var qrGolden = []struct {
+	in   string
+	rows []string
+}{
+	{"Hello, world", []string{
+		"#######...#...#######",
+		"#.....#...###.#.....#",
+		"#.###.#..#..#.#.###.#",
+		"#.###.#...###.#.###.#",
+		"#.###.#..#.##.#.###.#",
+		"#.....#.##....#.....#",
+		"#######.#.#.#.#######",
+		".....................",
+		"#..#.##.#.####.#.....",
+		".#..#....###....#..##",
+		".#.#..#...##...#.##.#",
+		"#####..###..#.##.#.##",
+		".#.####.#..#....#....",
+		"........#..#.###..#..",
+		"#######..#.####.####.",
+		"#.....#.#..#...#...#.",
+		"#.###.#...###..##....",
+		"#.###.#.##..#########",
+		"#.###.#..#.##...#.#.#",
+		"#.....#..###.#.......",
+		"#######.#.#...##.#.#.",
+	}},
+	{strings.Repeat("x1-_", 30), []string{
+		"#######.#..####...#.#....######.##..#.#######",
+		"#.....#..#.#...##..#.#.#.##.#.#....#..#.....#",
+		"#.###.#.#..#......#...#....#.#.#.#.#..#.###.#",
+		"#.###.#..#.#....#..###.####........##.#.###.#",
+		"#.###.#..#.#..#.##..#####.#.###.#.###.#.###.#",
+		"#.....#.#.#.#....##.#...###..##..#....#.....#",
+		"#######.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#.#######",
+		".............####.###...####.###..#.#........",
+		"#.#...##..#.##..#...#####...#...####...#..#.#",
+		"####.#...#.##..##..##..###.#.#.#.........####",
+		"###.#.#.#..###.#.#.##.#.#...........#..#...##",
+		"..##......#.####.......#####.######..#####...",
+		".####.#.##.#.###.##..#....#.#.#.#...#..##..#.",
+		"####.#.#.####.#######.####.#.#.###.#.#...####",
+		"##....#..###..##.....####.#...#.....#..#..###",
+		"..##.#.#######...##........############.##...",
+		"......###.....#.#.###..#..#.#.###.#...###.##.",
+		".#.###..#.#...#####.#.#.#..###...#.#.#.#....#",
+		"#...###.#..###.....#.#####....##..#...###.###",
+		"....##..####..#..#.....#..###..##..##..#...#.",
+		"##########.##...#.#.#########.###.#.######.##",
+		".#..#...#....#...#..#...#..........##...#..#.",
+		"...##.#.#..##########.#.#..#.#.#.#..#.#.#.#.#",
+		"##..#...##..#.#.##..#...#.#.#.#.#...#...#..#.",
+		"#...#####..##.#####.#############.#.######...",
+		"#.#..#.#....##.#.#..###....#...#...###.#..###",
+		".#..#.##.#.....#...#....#..##..###...##.###.#",
+		"###....#.#..##....#.####.##.####..##..###....",
+		"...#..##.##..##.......#.#..##...#######....#.",
+		".#.#.#..#.##..#..#..######.#.#.#.....#.#.####",
+		"##.##.##...##.##.#.#....#..##.......###.....#",
+		"..#..#.##..####..#..##.#.###.#######..####.##",
+		".###.###.#.....#......#.#.#.#...#..#.#.......",
+		".##.##.#..#.#.##...###.###.#.#.###.....#.####",
+		"....#.#...#.....#.##.##...#........#.##...###",
+		".####..#.#..#..##..###.#...########.######...",
+		"#..##.#...#.##......#####.#.#.#.#.#######.##.",
+		"........###.#...##.##...#..###.###.##...###.#",
+		"#######.#.......###.#.#.##....#...###.#.#.###",
+		"#.....#...####.#.##.#...#..##..######...##.#.",
+		"#.###.#...#.#...##.######.###.###.#.#####.###",
+		"#.###.#..#..#.#..##....##........#.#.####..##",
+		"#.###.#.###..###.#.#.#...#.#.#.#..#.#..##.#.#",
+		"#.....#......#...#..#..##.###.#.#..#.#.......",
+		"#######.#...#.##.#.##.###########.#....#.#..#",
+	}},
+}
+
+func TestQRCodeGolden(t *testing.T) {
+	for _, g := range qrGolden {
+		modules := mustQR(t, g.in)
+		if len(modules) != len(g.rows) {
+			t.Fatalf("%.12q size = %d, want %d", g.in, len(modules), len(g.rows))
+		}
+		for y, row := range g.rows {
+			for x, c := range row {
+				if modules[y][x] != (c == '#') {
+					t.Fatalf("%.12q differs at (%d, %d)", g.in, x, y)
+				}
+			}
+		}
+	}
+}
+
+func mustQR(t *testing.T, s string) [][]bool {
+	t.Helper()
+	m, err := TextQRCode(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestQRCodeTerminal(t *testing.T) {
+	out, err := TextQRCodeTerminal("tkstar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	// 版本 1 为 21 个模块，加上两侧各 4 个留白
+	if len(lines) != 15 || len([]rune(lines[0])) != 29 || strings.Trim(lines[0], "█") != "" || strings.Trim(lines[1], "█") != "" {
+		t.Fatalf("terminal output:\n%s", out)
+	}
+}
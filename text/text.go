@@ -180,6 +180,55 @@ func TextAesDecrypt(cipherText, key string) (string, error) {
 	return string(cipherTextBytes), nil
 }
 
+// TextAesGcmEncrypt 使用AES-GCM模式加密文本。
+// 与 TextAesEncrypt 相比额外提供完整性校验，密文被篡改时解密会失败。
+// 密钥长度同样为16、24或32字节，返回URL安全的Base64编码（nonce+密文）。
+func TextAesGcmEncrypt(plainText, key string) (string, error) {
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return "", fmt.Errorf("创建密码块失败: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("创建GCM失败: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(crand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+
+	cipherText := gcm.Seal(nonce, nonce, []byte(plainText), nil)
+	return base64.URLEncoding.EncodeToString(cipherText), nil
+}
+
+// TextAesGcmDecrypt 解密 TextAesGcmEncrypt 返回的数据，校验失败时返回错误。
+func TextAesGcmDecrypt(cipherText, key string) (string, error) {
+	cipherTextBytes, err := base64.URLEncoding.DecodeString(cipherText)
+	if err != nil {
+		return "", fmt.Errorf("Base64解码失败: %w", err)
+	}
+
+	block, err := aes.NewCipher([]byte(key))
+	if err != nil {
+		return "", fmt.Errorf("创建密码块失败: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("创建GCM失败: %w", err)
+	}
+
+	if len(cipherTextBytes) < gcm.NonceSize() {
+		return "", fmt.Errorf("密文过短，无法解密")
+	}
+	nonce, data := cipherTextBytes[:gcm.NonceSize()], cipherTextBytes[gcm.NonceSize():]
+	plainText, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", fmt.Errorf("解密校验失败: %w", err)
+	}
+	return string(plainText), nil
+}
+
 func encodeRSAPlaintext(plaintext []byte) []byte {
 	encoded := make([]byte, 5+len(plaintext))
 	encoded[0] = 1
@@ -2,6 +2,7 @@ package text
 
 import (
 	"bytes"
+	"encoding/base64"
 	"testing"
 )
 
@@ -26,3 +27,29 @@ func TestTextEncryptDecryptPreservesLeadingZeroBytes(t *testing.T) {
 		t.Fatalf("decrypted = %v, want %v", decrypted, plaintext)
 	}
 }
+
+func TestTextAesGcmRejectsTamperedCipherText(t *testing.T) {
+	key := "1234567890abcdef"
+	cipherText, err := TextAesGcmEncrypt("hello", key)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+
+	plainText, err := TextAesGcmDecrypt(cipherText, key)
+	if err != nil || plainText != "hello" {
+		t.Fatalf("decrypt = %q, %v", plainText, err)
+	}
+
+	// 在解码后的原始字节上修改再重新编码，确保走到 GCM 认证而不是 base64 解码失败
+	raw, err := base64.URLEncoding.DecodeString(cipherText)
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	for i := range raw {
+		tampered := bytes.Clone(raw)
+		tampered[i] ^= 0x01
+		if _, err := TextAesGcmDecrypt(base64.URLEncoding.EncodeToString(tampered), key); err == nil {
+			t.Fatalf("expected cipher text with byte %d flipped to be rejected", i)
+		}
+	}
+}